@@ -60,5 +60,7 @@ func main() {
 		logger.Info("Server exited gracefully")
 	}
 
+	container.Close()
+
 	logger.Sync()
 }
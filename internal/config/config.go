@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"exchange-rate-service/internal/domain/config"
@@ -29,6 +30,7 @@ func Load() *config.Config {
 			Timeout:       getDurationEnv("FIAT_EXTERNAL_API_TIMEOUT", 10*time.Second),
 			RetryAttempts: getIntEnv("FIAT_EXTERNAL_API_RETRY_ATTEMPTS", 3),
 			RetryDelay:    getDurationEnv("FIAT_EXTERNAL_API_RETRY_DELAY", 1*time.Second),
+			Breaker:       getBreakerConfig("FIAT_EXTERNAL_API"),
 		},
 		CryptoExternalAPI: config.ExternalAPIConfig{
 			BaseURL:       getEnv("CRYPTO_EXTERNAL_API_BASE_URL", "http://api.coinlayer.com/"),
@@ -36,15 +38,64 @@ func Load() *config.Config {
 			Timeout:       getDurationEnv("CRYPTO_EXTERNAL_API_TIMEOUT", 10*time.Second),
 			RetryAttempts: getIntEnv("CRYPTO_EXTERNAL_API_RETRY_ATTEMPTS", 3),
 			RetryDelay:    getDurationEnv("CRYPTO_EXTERNAL_API_RETRY_DELAY", 1*time.Second),
+			Breaker:       getBreakerConfig("CRYPTO_EXTERNAL_API"),
+		},
+		Providers: config.ProvidersConfig{
+			Fiat:            getListEnv("FIAT_PROVIDERS", []string{"exchangerate-api"}),
+			Crypto:          getListEnv("CRYPTO_PROVIDERS", []string{"coinlayer"}),
+			Metal:           getListEnv("METAL_PROVIDERS", nil),
+			MaxStaleness:    getDurationEnv("PROVIDER_MAX_STALENESS", 1*time.Hour),
+			Mode:            getEnv("PROVIDER_MODE", "failover"),
+			MaxDeviationBps: getFloatEnv("CONSENSUS_MAX_DEVIATION_BPS", 100),
+		},
+		Pivot: config.PivotConfig{
+			Currency:        getEnv("PIVOT_CURRENCY", ""),
+			CompareDirect:   getBoolEnv("PIVOT_COMPARE_DIRECT", false),
+			MaxDeviationBps: getFloatEnv("PIVOT_MAX_DEVIATION_BPS", 50),
 		},
 		Cache: config.CacheConfig{
-			TTL:               getDurationEnv("CACHE_TTL", 1*time.Hour),
-			RefreshInterval:   getDurationEnv("CACHE_REFRESH_INTERVAL", 1*time.Hour),
-			MaxHistoricalDays: getIntEnv("MAX_HISTORICAL_DAYS", 90),
+			Backend:            getEnv("CACHE_BACKEND", "memory"),
+			TTL:                getDurationEnv("CACHE_TTL", 1*time.Hour),
+			CryptoTTL:          getDurationEnv("CACHE_CRYPTO_TTL", 5*time.Minute),
+			HistoricalTTL:      getDurationEnv("CACHE_HISTORICAL_TTL", 0),
+			MaxStaleAge:        getDurationEnv("CACHE_MAX_STALE_AGE", 24*time.Hour),
+			MaxEntries:         getIntEnv("CACHE_MAX_ENTRIES", 10000),
+			MaxBytes:           int64(getIntEnv("CACHE_MAX_BYTES", 64<<20)),
+			RefreshInterval:    getDurationEnv("CACHE_REFRESH_INTERVAL", 1*time.Hour),
+			RefreshConcurrency: getIntEnv("CACHE_REFRESH_CONCURRENCY", 4),
+			RefreshTimeout:     getDurationEnv("CACHE_REFRESH_TIMEOUT", 1*time.Minute),
+			MaxHistoricalDays:  getIntEnv("MAX_HISTORICAL_DAYS", 90),
+			Redis: config.RedisConfig{
+				Addr:      getEnv("REDIS_ADDR", "localhost:6379"),
+				Password:  getEnv("REDIS_PASSWORD", ""),
+				DB:        getIntEnv("REDIS_DB", 0),
+				KeyPrefix: getEnv("REDIS_KEY_PREFIX", "exchange-rate:"),
+			},
+		},
+		Money: config.MoneyConfig{
+			RoundingMode:  getEnv("ROUNDING_MODE", "half-even"),
+			MaxBatchSize:  getIntEnv("CONVERT_BATCH_MAX_SIZE", 1000),
+			MaxBatchBytes: getIntEnv("CONVERT_BATCH_MAX_BYTES", 1<<20),
+		},
+		Currencies: config.CurrencyConfig{
+			RegistryPath:   getEnv("CURRENCY_REGISTRY_PATH", ""),
+			ReloadInterval: getDurationEnv("CURRENCY_REGISTRY_RELOAD_INTERVAL", 30*time.Second),
+		},
+		History: config.HistoryConfig{
+			Driver: getEnv("HISTORY_STORE_DRIVER", ""),
+			DSN:    getEnv("HISTORY_STORE_DSN", "file:exchange_rates.db"),
 		},
 	}
 }
 
+func getBreakerConfig(prefix string) config.BreakerConfig {
+	return config.BreakerConfig{
+		FailureThreshold: getIntEnv(prefix+"_BREAKER_FAILURE_THRESHOLD", 5),
+		SuccessThreshold: getIntEnv(prefix+"_BREAKER_SUCCESS_THRESHOLD", 1),
+		OpenTimeout:      getDurationEnv(prefix+"_BREAKER_OPEN_TIMEOUT", 30*time.Second),
+	}
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -52,6 +103,21 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getListEnv(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getIntEnv(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -61,6 +127,24 @@ func getIntEnv(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errorMappings translates domain error kinds into HTTP statuses and stable
+// codes. The first match wins, so an upstream failure caused by rate limiting
+// or an open circuit is reported as such.
+var errorMappings = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{domain_exchange.ErrRateLimited, http.StatusServiceUnavailable, "rate_limited"},
+	{domain_exchange.ErrCircuitOpen, http.StatusServiceUnavailable, "upstream_unavailable"},
+	{domain_exchange.ErrInvalidRequest, http.StatusBadRequest, "invalid_request"},
+	{domain_exchange.ErrUnsupportedCurrency, http.StatusUnprocessableEntity, "unsupported_currency"},
+	{domain_exchange.ErrCurrencyNotFound, http.StatusNotFound, "currency_not_found"},
+	{domain_exchange.ErrDateOutOfRange, http.StatusUnprocessableEntity, "date_out_of_range"},
+	{domain_exchange.ErrRateUnavailable, http.StatusNotFound, "rate_unavailable"},
+	{domain_exchange.ErrUpstreamFailure, http.StatusBadGateway, "upstream_failure"},
+}
+
+// classifyError returns the status and code for err, falling back to an
+// internal error for failures the domain did not classify.
+func classifyError(err error) (int, string) {
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.kind) {
+			return mapping.status, mapping.code
+		}
+	}
+	return http.StatusInternalServerError, "internal_error"
+}
+
+func errorBody(code, message string) gin.H {
+	return gin.H{"code": code, "message": message}
+}
+
+// respondError renders err in the {"error": {"code", "message"}} envelope.
+func respondError(c *gin.Context, err error) {
+	status, code := classifyError(err)
+	c.JSON(status, gin.H{"error": errorBody(code, err.Error())})
+}
+
+// itemError is the envelope body for an error reported inside a response,
+// such as a failed item of a batch.
+func itemError(err error) gin.H {
+	_, code := classifyError(err)
+	return errorBody(code, err.Error())
+}
+
+// respondInvalid rejects a request the handler could not parse.
+func respondInvalid(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, gin.H{"error": errorBody("invalid_request", message)})
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"invalid request", domain_exchange.Errorf(domain_exchange.ErrInvalidRequest, "amount must be greater than 0"), http.StatusBadRequest, "invalid_request"},
+		{"unsupported currency", domain_exchange.Errorf(domain_exchange.ErrUnsupportedCurrency, "currency XYZ is not supported"), http.StatusUnprocessableEntity, "unsupported_currency"},
+		{"currency not found", domain_exchange.Errorf(domain_exchange.ErrCurrencyNotFound, "currency XYZ is not supported"), http.StatusNotFound, "currency_not_found"},
+		{"date out of range", domain_exchange.Errorf(domain_exchange.ErrDateOutOfRange, "date cannot be in the future"), http.StatusUnprocessableEntity, "date_out_of_range"},
+		{"rate unavailable", domain_exchange.Errorf(domain_exchange.ErrRateUnavailable, "conversion rate from USD to XAU not found"), http.StatusNotFound, "rate_unavailable"},
+		{"upstream failure", domain_exchange.Errorf(domain_exchange.ErrUpstreamFailure, "failed to fetch latest rate: %w", errors.New("timeout")), http.StatusBadGateway, "upstream_failure"},
+		{
+			"rate limited behind upstream failure",
+			domain_exchange.Errorf(domain_exchange.ErrUpstreamFailure, "failed to fetch latest rate: %w",
+				domain_exchange.Errorf(domain_exchange.ErrRateLimited, "API returned status 429")),
+			http.StatusServiceUnavailable, "rate_limited",
+		},
+		{
+			"open circuit in a failover chain",
+			errors.Join(errors.New("primary: timeout"), fmt.Errorf("secondary: %w", domain_exchange.ErrCircuitOpen)),
+			http.StatusServiceUnavailable, "upstream_unavailable",
+		},
+		{"unclassified", context.Canceled, http.StatusInternalServerError, "internal_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code := classifyError(tt.err)
+			if status != tt.status || code != tt.code {
+				t.Fatalf("classifyError() = %d, %s; want %d, %s", status, code, tt.status, tt.code)
+			}
+		})
+	}
+}
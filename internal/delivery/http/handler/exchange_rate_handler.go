@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
 
 	domain_exchange "exchange-rate-service/internal/domain/exchange"
@@ -10,12 +13,21 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// Options holds the request limits enforced by the handler.
+type Options struct {
+	// MaxBatchSize caps the items of a batch request and MaxBatchBytes its
+	// body; zero means no cap.
+	MaxBatchSize  int
+	MaxBatchBytes int64
+}
+
 type ExchangeRateHandler struct {
 	usecase domain_exchange.ExchangeRateUsercase
+	options Options
 }
 
-func NewExchangeRateHandler(u domain_exchange.ExchangeRateUsercase) *ExchangeRateHandler {
-	return &ExchangeRateHandler{usecase: u}
+func NewExchangeRateHandler(u domain_exchange.ExchangeRateUsercase, options Options) *ExchangeRateHandler {
+	return &ExchangeRateHandler{usecase: u, options: options}
 }
 
 func ParseDate(dateStr string) (time.Time, error) {
@@ -37,40 +49,332 @@ func (h *ExchangeRateHandler) ConvertAmount(c *gin.Context) {
 	fromDate := c.Query("fromDate")
 	toDate := c.Query("toDate")
 
-	amount, err := strconv.ParseFloat(amountStr, 64)
-	if err != nil || amount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid amount"})
+	amount, err := domain_exchange.ParseDecimal(amountStr)
+	if err != nil || amount.Sign() <= 0 {
+		respondInvalid(c, "Invalid amount")
 		return
 	}
 
 	fromTargetDate, err := ParseDate(fromDate)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date format. Use YYYY-MM-DD"})
+		respondInvalid(c, "Invalid from date format. Use YYYY-MM-DD")
 		return
 	}
 
 	toTargetDate, err := ParseDate(toDate)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date format. Use YYYY-MM-DD"})
+		respondInvalid(c, "Invalid to date format. Use YYYY-MM-DD")
+		return
+	}
+
+	conversion, err := h.usecase.ConvertAmount(c, domain_exchange.ConversionRequest{
+		From:     from,
+		To:       to,
+		Amount:   amount,
+		FromDate: fromTargetDate,
+		ToDate:   toTargetDate,
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conversion)
+}
+
+type batchConvertItem struct {
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Amount json.RawMessage `json:"amount"`
+	Date   string          `json:"date"`
+}
+
+// ConvertBatch converts a JSON array of items. Items that cannot be parsed or
+// converted carry their own error; the others are still converted.
+func (h *ExchangeRateHandler) ConvertBatch(c *gin.Context) {
+	if h.options.MaxBatchBytes > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.options.MaxBatchBytes)
+	}
+	var request []batchConvertItem
+	if err := c.ShouldBindJSON(&request); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondInvalid(c, fmt.Sprintf("Request body exceeds the limit of %d bytes", tooLarge.Limit))
+			return
+		}
+		respondInvalid(c, "Invalid request body. Expected a JSON array of {from,to,amount,date} items")
+		return
+	}
+	if len(request) == 0 {
+		respondInvalid(c, "Batch must contain at least one item")
+		return
+	}
+	if h.options.MaxBatchSize > 0 && len(request) > h.options.MaxBatchSize {
+		respondInvalid(c, fmt.Sprintf("Batch of %d items exceeds the limit of %d", len(request), h.options.MaxBatchSize))
+		return
+	}
+
+	responses := make([]gin.H, len(request))
+	items := make([]domain_exchange.BatchConversionItem, 0, len(request))
+	indexes := make([]int, 0, len(request))
+	for i, entry := range request {
+		responses[i] = gin.H{"index": i, "from": entry.From, "to": entry.To, "date": entry.Date}
+
+		var amount domain_exchange.Decimal
+		if err := amount.UnmarshalJSON(entry.Amount); err != nil || amount.Sign() <= 0 {
+			responses[i]["error"] = errorBody("invalid_request", "Invalid amount")
+			continue
+		}
+		responses[i]["amount"] = amount
+		date, err := ParseDate(entry.Date)
+		if err != nil {
+			responses[i]["error"] = errorBody("invalid_request", "Invalid date format. Use YYYY-MM-DD")
+			continue
+		}
+		items = append(items, domain_exchange.BatchConversionItem{From: entry.From, To: entry.To, Amount: amount, Date: date})
+		indexes = append(indexes, i)
+	}
+
+	if len(items) > 0 {
+		results, err := h.usecase.ConvertBatch(c, items)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		for j, result := range results {
+			response := responses[indexes[j]]
+			if result.Err != nil {
+				response["error"] = itemError(result.Err)
+				continue
+			}
+			response["converted"] = result.Converted
+			response["rate"] = result.Rate
+			response["path"] = result.Path
+		}
+	}
+
+	failed := 0
+	for _, response := range responses {
+		if _, exists := response["error"]; exists {
+			failed++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"results":   responses,
+		"succeeded": len(responses) - failed,
+		"failed":    failed,
+	})
+}
+
+func (h *ExchangeRateHandler) GetLatestRate(c *gin.Context) {
+	from := c.Query("from")
+	to := c.Query("to")
+
+	if to == "" {
+		table, err := h.usecase.GetLatestRates(c, from)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"base":        table.BaseCode,
+			"rates":       table.ConversionRates,
+			"fetched_at":  table.FetchedAt,
+			"provider":    table.Provider,
+			"stale":       table.Stale,
+			"age_seconds": int64(table.Age().Seconds()),
+		})
+		return
+	}
+
+	// A single pair resolves like a conversion, so pairs no one table quotes
+	// are bridged the same way.
+	rate, err := h.usecase.GetLatestRate(c, from, to)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"base":        rate.From,
+		"to":          rate.To,
+		"rate":        rate.Rate,
+		"path":        rate.Path,
+		"fetched_at":  rate.FetchedAt,
+		"provider":    rate.Provider,
+		"stale":       rate.Stale,
+		"age_seconds": int64(time.Since(rate.FetchedAt).Seconds()),
+	})
+}
+
+func (h *ExchangeRateHandler) GetTimeSeries(c *gin.Context) {
+	from := c.Query("from")
+	to := c.Query("to")
+
+	start, err := ParseDate(c.Query("start"))
+	if err != nil || start.IsZero() {
+		respondInvalid(c, "Invalid start date format. Use YYYY-MM-DD")
+		return
+	}
+
+	end, err := ParseDate(c.Query("end"))
+	if err != nil || end.IsZero() {
+		respondInvalid(c, "Invalid end date format. Use YYYY-MM-DD")
+		return
+	}
+
+	series, err := h.usecase.GetTimeSeries(c, from, to, start, end)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	fromAmount, toAmount, fromRate, toRate, err := h.usecase.ConvertAmount(c, from, to, amount, fromTargetDate, toTargetDate)
+	points := make([]gin.H, 0, len(series.Points))
+	for _, point := range series.Points {
+		points = append(points, gin.H{
+			"date": point.Date.Format("2006-01-02"),
+			"rate": point.Rate,
+		})
+	}
+
+	missingDays := make([]string, 0, len(series.MissingDays))
+	for _, day := range series.MissingDays {
+		missingDays = append(missingDays, day.Format("2006-01-02"))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":         series.From,
+		"to":           series.To,
+		"start":        series.Start.Format("2006-01-02"),
+		"end":          series.End.Format("2006-01-02"),
+		"points":       points,
+		"missing_days": missingDays,
+	})
+}
 
+func (h *ExchangeRateHandler) GetRateChange(c *gin.Context) {
+	from := c.Query("from")
+	to := c.Query("to")
+
+	start, err := ParseDate(c.Query("start"))
+	if err != nil || start.IsZero() {
+		respondInvalid(c, "Invalid start date format. Use YYYY-MM-DD")
+		return
+	}
+
+	end, err := ParseDate(c.Query("end"))
+	if err != nil || end.IsZero() {
+		respondInvalid(c, "Invalid end date format. Use YYYY-MM-DD")
+		return
+	}
+
+	change, err := h.usecase.GetRateChange(c, from, to, start, end)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
+	missingDays := make([]string, 0, len(change.MissingDays))
+	for _, day := range change.MissingDays {
+		missingDays = append(missingDays, day.Format("2006-01-02"))
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"from":              from,
-		"to":                to,
-		"original_amount":   amount,
-		"converted_at_from": fromAmount,
-		"converted_at_to":   toAmount,
-		"from_rate":         fromRate,
-		"to_rate":           toRate,
-		"from_date":         fromTargetDate,
-		"to_date":           toTargetDate,
+		"from":            change.From,
+		"to":              change.To,
+		"start":           change.StartDate.Format("2006-01-02"),
+		"end":             change.EndDate.Format("2006-01-02"),
+		"start_rate":      change.StartRate,
+		"end_rate":        change.EndRate,
+		"absolute_change": change.AbsoluteChange,
+		"percent_change":  change.PercentChange,
+		"min":             change.Min,
+		"max":             change.Max,
+		"mean":            change.Mean,
+		"volatility":      change.Volatility,
+		"points":          change.Points,
+		"missing_days":    missingDays,
 	})
 }
+
+func (h *ExchangeRateHandler) GetRateMatrix(c *gin.Context) {
+	var currencies []string
+	for _, code := range strings.Split(c.Query("currencies"), ",") {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+			currencies = append(currencies, code)
+		}
+	}
+
+	date, err := ParseDate(c.Query("date"))
+	if err != nil {
+		respondInvalid(c, "Invalid date format. Use YYYY-MM-DD")
+		return
+	}
+
+	checkInverse := c.Query("check_inverse") == "true"
+	matrix, err := h.usecase.GetRateMatrix(c, currencies, date, checkInverse)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	response := gin.H{
+		"currencies": matrix.Currencies,
+		"rates":      matrix.Rates,
+		"missing":    append([]string{}, matrix.Missing...),
+	}
+	if !matrix.Date.IsZero() {
+		response["date"] = matrix.Date.Format("2006-01-02")
+	}
+	if checkInverse {
+		inconsistencies := make([]gin.H, 0, len(matrix.Inconsistencies))
+		for _, inconsistency := range matrix.Inconsistencies {
+			inconsistencies = append(inconsistencies, gin.H{
+				"from":          inconsistency.From,
+				"to":            inconsistency.To,
+				"deviation_bps": inconsistency.DeviationBps,
+			})
+		}
+		response["inconsistencies"] = inconsistencies
+		response["unchecked"] = append([]string{}, matrix.Unchecked...)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *ExchangeRateHandler) GetCurrencies(c *gin.Context) {
+	currencies, err := h.usecase.ListCurrencies(c.Query("type"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(currencies))
+	for _, currency := range currencies {
+		items = append(items, currencyResponse(currency))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"currencies": items})
+}
+
+func (h *ExchangeRateHandler) GetCurrency(c *gin.Context) {
+	currency, err := h.usecase.GetCurrency(strings.ToUpper(c.Param("code")))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, currencyResponse(*currency))
+}
+
+func currencyResponse(currency domain_exchange.CurrencyInfo) gin.H {
+	return gin.H{
+		"code":                currency.Code,
+		"numeric":             currency.Numeric,
+		"name":                currency.Name,
+		"symbol":              currency.Symbol,
+		"type":                currency.Type,
+		"minor_units":         currency.MinorUnits,
+		"provider":            currency.Provider,
+		"oldest_allowed_date": currency.OldestAllowedDate.Format("2006-01-02"),
+	}
+}
@@ -1,13 +1,17 @@
 package handler
 
 import (
-	"errors"
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,50 +23,180 @@ type mockUsecase struct {
 	err    error
 }
 
-func (m *mockUsecase) GetLatestRate(from, to string) (float64, error) { return m.latest, m.err }
-func (m *mockUsecase) ConvertAmount(from, to string, amount float64, date time.Time) (float64, float64, error) {
-	return m.rate, m.amt, m.err
+func (m *mockUsecase) GetLatestRate(ctx context.Context, from, to string) (*domain_exchange.PairRate, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &domain_exchange.PairRate{
+		From:      from,
+		To:        to,
+		Rate:      m.latest,
+		Path:      domain_exchange.ConversionPath{from, to},
+		FetchedAt: time.Now(),
+		Provider:  "mock",
+	}, nil
+}
+func (m *mockUsecase) GetLatestRates(ctx context.Context, from string) (*domain_exchange.ExchangeRate, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &domain_exchange.ExchangeRate{
+		Result:          "success",
+		BaseCode:        from,
+		ConversionRates: map[string]float64{from: 1, "USD": m.latest},
+		FetchedAt:       time.Now(),
+		Provider:        "mock",
+	}, nil
+}
+func (m *mockUsecase) ConvertAmount(ctx context.Context, request domain_exchange.ConversionRequest) (*domain_exchange.Conversion, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	amt := domain_exchange.NewDecimalFromFloat(m.amt)
+	rate := domain_exchange.NewDecimalFromFloat(m.rate)
+	if !request.FromDate.IsZero() {
+		rate = domain_exchange.NewDecimalFromFloat(m.hist)
+	}
+	return &domain_exchange.Conversion{
+		From:            request.From,
+		To:              request.To,
+		OriginalAmount:  request.Amount,
+		ConvertedAtFrom: amt,
+		ConvertedAtTo:   amt,
+		FromRate:        rate,
+		ToRate:          rate,
+		Provider:        "mock",
+		Path:            domain_exchange.ConversionPath{request.From, request.To},
+	}, nil
+}
+func (m *mockUsecase) ConvertBatch(ctx context.Context, items []domain_exchange.BatchConversionItem) ([]domain_exchange.BatchConversionResult, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	results := make([]domain_exchange.BatchConversionResult, len(items))
+	for i, item := range items {
+		if item.To == "XXX" {
+			results[i].Err = domain_exchange.Errorf(domain_exchange.ErrUnsupportedCurrency, "currency XXX is not supported")
+			continue
+		}
+		results[i] = domain_exchange.BatchConversionResult{
+			Converted: domain_exchange.NewDecimalFromFloat(m.amt),
+			Rate:      domain_exchange.NewDecimalFromFloat(m.rate),
+			Path:      domain_exchange.ConversionPath{item.From, item.To},
+		}
+	}
+	return results, nil
+}
+func (m *mockUsecase) GetRateChange(ctx context.Context, from, to string, start, end time.Time) (*domain_exchange.RateChange, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &domain_exchange.RateChange{
+		From:           from,
+		To:             to,
+		StartDate:      start,
+		EndDate:        end,
+		StartRate:      m.rate,
+		EndRate:        m.hist,
+		AbsoluteChange: m.hist - m.rate,
+		PercentChange:  (m.hist - m.rate) / m.rate * 100,
+		Points:         2,
+		MissingDays:    []time.Time{},
+	}, nil
+}
+func (m *mockUsecase) GetRateMatrix(ctx context.Context, currencies []string, date time.Time, checkInverse bool) (*domain_exchange.RateMatrix, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	matrix := &domain_exchange.RateMatrix{Currencies: currencies, Date: date, Rates: map[string]map[string]float64{}}
+	for _, from := range currencies {
+		matrix.Rates[from] = map[string]float64{}
+		for _, to := range currencies {
+			matrix.Rates[from][to] = m.rate
+		}
+	}
+	if checkInverse {
+		matrix.Inconsistencies = []domain_exchange.RateInconsistency{{From: currencies[0], To: currencies[1], DeviationBps: 3}}
+	}
+	return matrix, nil
+}
+func (m *mockUsecase) GetTimeSeries(ctx context.Context, from, to string, start, end time.Time) (*domain_exchange.TimeSeries, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &domain_exchange.TimeSeries{
+		From:        from,
+		To:          to,
+		Start:       start,
+		End:         end,
+		Points:      []domain_exchange.RatePoint{{Date: start, Rate: m.hist}},
+		MissingDays: []time.Time{end},
+	}, nil
 }
-func (m *mockUsecase) GetHistoricalRate(from, to string, date time.Time) (float64, error) {
-	return m.hist, m.err
+func (m *mockUsecase) ListCurrencies(currencyType string) ([]domain_exchange.CurrencyInfo, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []domain_exchange.CurrencyInfo{{Currency: domain_exchange.DefaultCurrencies["USD"], Provider: "mock"}}, nil
+}
+func (m *mockUsecase) GetCurrency(code string) (*domain_exchange.CurrencyInfo, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &domain_exchange.CurrencyInfo{Currency: domain_exchange.DefaultCurrencies[code], Provider: "mock"}, nil
+}
+func (m *mockUsecase) RefreshRates(ctx context.Context) (*domain_exchange.RefreshResult, error) {
+	return &domain_exchange.RefreshResult{}, m.err
+}
+func (m *mockUsecase) ValidateCurrencies(from, to string) error {
+	return nil
+}
+func (m *mockUsecase) ValidateDate(date time.Time, maxHistoricalDays int) error {
+	return nil
 }
 
 func TestGetLatestRate_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mu := &mockUsecase{latest: 1.5}
-	h := NewExchangeRateHandler(mu)
+	h := NewExchangeRateHandler(mu, Options{})
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	q := url.Values{"from": {"EUR"}, "to": {"USD"}}
 	req := httptest.NewRequest(http.MethodGet, "/api/latest?"+q.Encode(), nil)
 	c.Request = req
 
-	h.ConvertAmount(c)
+	h.GetLatestRate(c)
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d, body=%s", w.Code, w.Body.String())
 	}
+	if !strings.Contains(w.Body.String(), `"path":["EUR","USD"]`) {
+		t.Fatalf("expected the conversion path in body, got %s", w.Body.String())
+	}
 }
 
 func TestGetLatestRate_Error(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	mu := &mockUsecase{err: errors.New("this is the error that would propagate")}
-	h := NewExchangeRateHandler(mu)
+	mu := &mockUsecase{err: domain_exchange.Errorf(domain_exchange.ErrUpstreamFailure, "this is the error that would propagate")}
+	h := NewExchangeRateHandler(mu, Options{})
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	q := url.Values{"from": {"EUR"}, "to": {"USD"}}
 	c.Request = httptest.NewRequest(http.MethodGet, "/api/latest?"+q.Encode(), nil)
 
-	h.ConvertAmount(c)
+	h.GetLatestRate(c)
 
-	if w.Code != http.StatusBadRequest {
-		t.Fatalf("expected 400, got %d, body=%s", w.Code, w.Body.String())
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d, body=%s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"code":"upstream_failure"`) {
+		t.Fatalf("expected upstream_failure code, got %s", w.Body.String())
 	}
 }
 
 func TestConvertAmount_InvalidAmount(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	h := NewExchangeRateHandler(&mockUsecase{})
+	h := NewExchangeRateHandler(&mockUsecase{}, Options{})
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	q := url.Values{"from": {"EUR"}, "to": {"USD"}, "amount": {"-1"}}
@@ -78,7 +212,7 @@ func TestConvertAmount_InvalidAmount(t *testing.T) {
 func TestConvertAmount_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mu := &mockUsecase{rate: 2, amt: 20}
-	h := NewExchangeRateHandler(mu)
+	h := NewExchangeRateHandler(mu, Options{})
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	q := url.Values{"from": {"EUR"}, "to": {"USD"}, "amount": {"10"}, "date": {"2024-01-02"}}
@@ -89,11 +223,18 @@ func TestConvertAmount_Success(t *testing.T) {
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d, body=%s", w.Code, w.Body.String())
 	}
+	var conversion domain_exchange.Conversion
+	if err := json.Unmarshal(w.Body.Bytes(), &conversion); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if conversion.ConvertedAtFrom.String() != "20" || conversion.Provider != "mock" || conversion.Path.String() != "EUR->USD" {
+		t.Fatalf("unexpected conversion: %s", w.Body.String())
+	}
 }
 
 func TestGetHistoricalRate_InvalidDate(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	h := NewExchangeRateHandler(&mockUsecase{})
+	h := NewExchangeRateHandler(&mockUsecase{}, Options{})
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	q := url.Values{"from": {"EUR"}, "to": {"USD"}, "date": {"bad"}}
@@ -108,7 +249,7 @@ func TestGetHistoricalRate_InvalidDate(t *testing.T) {
 
 func TestGetHistoricalRate_TooOld(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	h := NewExchangeRateHandler(&mockUsecase{})
+	h := NewExchangeRateHandler(&mockUsecase{}, Options{})
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	old := time.Now().AddDate(0, 0, -91).Format("2006-01-02")
@@ -125,11 +266,11 @@ func TestGetHistoricalRate_TooOld(t *testing.T) {
 func TestGetHistoricalRate_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mu := &mockUsecase{hist: 1.1}
-	h := NewExchangeRateHandler(mu)
+	h := NewExchangeRateHandler(mu, Options{})
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	date := time.Now().AddDate(0, 0, -5).Format("2006-01-02")
-	q := url.Values{"from": {"EUR"}, "to": {"USD"}, "date": {date}}
+	q := url.Values{"from": {"EUR"}, "to": {"USD"}, "amount": {"10"}, "fromDate": {date}}
 	c.Request = httptest.NewRequest(http.MethodGet, "/api/historical?"+q.Encode(), nil)
 
 	h.ConvertAmount(c)
@@ -138,3 +279,224 @@ func TestGetHistoricalRate_Success(t *testing.T) {
 		t.Fatalf("expected 200, got %d, body=%s", w.Code, w.Body.String())
 	}
 }
+
+func TestGetLatestRate_WholeTable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mu := &mockUsecase{latest: 1.5}
+	h := NewExchangeRateHandler(mu, Options{})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	q := url.Values{"from": {"EUR"}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/latest?"+q.Encode(), nil)
+
+	h.GetLatestRate(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d, body=%s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"rates"`) {
+		t.Fatalf("expected rates table in body, got %s", w.Body.String())
+	}
+}
+
+func TestGetTimeSeries_MissingEnd(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewExchangeRateHandler(&mockUsecase{}, Options{})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	q := url.Values{"from": {"EUR"}, "to": {"USD"}, "start": {"2024-01-02"}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/timeseries?"+q.Encode(), nil)
+
+	h.GetTimeSeries(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d, body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetTimeSeries_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewExchangeRateHandler(&mockUsecase{hist: 1.1}, Options{})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	q := url.Values{"from": {"EUR"}, "to": {"USD"}, "start": {"2024-01-02"}, "end": {"2024-01-03"}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/timeseries?"+q.Encode(), nil)
+
+	h.GetTimeSeries(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d, body=%s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"missing_days":["2024-01-03"]`) {
+		t.Fatalf("expected missing day in body, got %s", w.Body.String())
+	}
+}
+
+func TestGetCurrency_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewExchangeRateHandler(&mockUsecase{err: domain_exchange.Errorf(domain_exchange.ErrCurrencyNotFound, "currency XYZ is not supported")}, Options{})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "code", Value: "xyz"}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/currencies/xyz", nil)
+
+	h.GetCurrency(c)
+
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), `"currency_not_found"`) {
+		t.Fatalf("expected 404 currency_not_found, got %d, body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestConvertBatch_ReportsPerItemErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mu := &mockUsecase{rate: 2, amt: 20}
+	h := NewExchangeRateHandler(mu, Options{})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	body := `[
+		{"from":"EUR","to":"USD","amount":"10"},
+		{"from":"EUR","to":"USD","amount":"ten"},
+		{"from":"EUR","to":"USD","amount":10,"date":"02-01-2024"},
+		{"from":"EUR","to":"XXX","amount":10,"date":"2024-01-02"}
+	]`
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/convert/batch", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	h.ConvertBatch(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d, body=%s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Results []struct {
+			Index     int    `json:"index"`
+			Converted string `json:"converted"`
+			Error     *struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		} `json:"results"`
+		Succeeded int `json:"succeeded"`
+		Failed    int `json:"failed"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if response.Succeeded != 1 || response.Failed != 3 || len(response.Results) != 4 {
+		t.Fatalf("unexpected summary: %s", w.Body.String())
+	}
+	if response.Results[0].Converted != "20" || response.Results[0].Error != nil {
+		t.Fatalf("expected first item converted: %+v", response.Results[0])
+	}
+	for i, code := range []string{"invalid_request", "invalid_request", "unsupported_currency"} {
+		if result := response.Results[i+1]; result.Error == nil || result.Error.Code != code {
+			t.Fatalf("expected item %d to fail with %s", result.Index, code)
+		}
+	}
+}
+
+func TestConvertBatch_EnforcesLimitsBeforeParsingItems(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		body    string
+	}{
+		{name: "too many invalid items", options: Options{MaxBatchSize: 1}, body: `[{"amount":"x"},{"amount":"y"}]`},
+		{name: "empty batch", options: Options{MaxBatchSize: 1}, body: `[]`},
+		{name: "body too large", options: Options{MaxBatchBytes: 16}, body: `[{"from":"EUR","to":"USD","amount":"1"}]`},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu := &mockUsecase{rate: 2, amt: 2}
+			h := NewExchangeRateHandler(mu, tt.options)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/convert/batch", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			h.ConvertBatch(c)
+
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"invalid_request"`) {
+				t.Fatalf("expected 400 invalid_request, got %d, body=%s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetRateMatrix_ParsesCurrencies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mu := &mockUsecase{rate: 2}
+	h := NewExchangeRateHandler(mu, Options{})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	q := url.Values{"currencies": {"usd, EUR,gbp"}, "check_inverse": {"true"}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/matrix?"+q.Encode(), nil)
+
+	h.GetRateMatrix(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d, body=%s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Currencies      []string                      `json:"currencies"`
+		Rates           map[string]map[string]float64 `json:"rates"`
+		Inconsistencies []map[string]any              `json:"inconsistencies"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if strings.Join(response.Currencies, ",") != "USD,EUR,GBP" || response.Rates["EUR"]["GBP"] != 2 {
+		t.Fatalf("unexpected matrix: %s", w.Body.String())
+	}
+	if len(response.Inconsistencies) != 1 {
+		t.Fatalf("expected inverse check results: %s", w.Body.String())
+	}
+}
+
+func TestGetRateMatrix_InvalidDate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewExchangeRateHandler(&mockUsecase{}, Options{})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/matrix?currencies=USD,EUR&date=2024/01/02", nil)
+
+	h.GetRateMatrix(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestGetRateChange_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewExchangeRateHandler(&mockUsecase{rate: 2, hist: 2.5}, Options{})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	q := url.Values{"from": {"EUR"}, "to": {"USD"}, "start": {"2024-01-02"}, "end": {"2024-01-05"}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/change?"+q.Encode(), nil)
+
+	h.GetRateChange(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d, body=%s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"percent_change":25`) || !strings.Contains(w.Body.String(), `"end":"2024-01-05"`) {
+		t.Fatalf("unexpected body %s", w.Body.String())
+	}
+}
+
+func TestGetRateChange_MissingStart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewExchangeRateHandler(&mockUsecase{}, Options{})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	q := url.Values{"from": {"EUR"}, "to": {"USD"}, "end": {"2024-01-05"}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/change?"+q.Encode(), nil)
+
+	h.GetRateChange(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d, body=%s", w.Code, w.Body.String())
+	}
+}
@@ -22,7 +22,13 @@ func SetupRoutes(exchangeRateHandler *handler.ExchangeRateHandler) *gin.Engine {
 	api := router.Group("/api/")
 	{
 		api.GET("/convert", exchangeRateHandler.ConvertAmount)
-
+		api.POST("/convert/batch", exchangeRateHandler.ConvertBatch)
+		api.GET("/latest", exchangeRateHandler.GetLatestRate)
+		api.GET("/timeseries", exchangeRateHandler.GetTimeSeries)
+		api.GET("/change", exchangeRateHandler.GetRateChange)
+		api.GET("/matrix", exchangeRateHandler.GetRateMatrix)
+		api.GET("/currencies", exchangeRateHandler.GetCurrencies)
+		api.GET("/currencies/:code", exchangeRateHandler.GetCurrency)
 	}
 
 	return router
@@ -2,27 +2,37 @@ package di
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"exchange-rate-service/internal/delivery/http/handler"
 	"exchange-rate-service/internal/domain/config"
 	domain_exchange "exchange-rate-service/internal/domain/exchange"
 	"exchange-rate-service/internal/infra/http_client"
+	"exchange-rate-service/internal/infra/registry"
 	"exchange-rate-service/internal/infra/repository/api"
 	"exchange-rate-service/internal/infra/repository/inmemory"
 	"exchange-rate-service/internal/infra/repository/mock"
+	"exchange-rate-service/internal/infra/repository/sqlstore"
 	usecase "exchange-rate-service/internal/usecase/exchange"
 
 	"exchange-rate-service/pkg/cache"
+	"exchange-rate-service/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
 )
 
 type InfraContainer struct {
-	HTTPClient http_client.HTTPClient
-	Cache      cache.Cache
+	FiatHTTPClient   http_client.HTTPClient
+	CryptoHTTPClient http_client.HTTPClient
+	Cache            cache.Cache
+	CurrencyRegistry domain_exchange.CurrencyRegistry
 }
 
 type RepositoryContainer struct {
 	ExternalAPIRepository domain_exchange.ExchangeRateExternalRepository
 	InMemoryRepository    domain_exchange.ExchangeRateCacheRepository
+	HistoryRepository     domain_exchange.ExchangeRateCacheRepository
 	MockRepository        domain_exchange.ExchangeRateExternalRepository
 }
 
@@ -44,38 +54,76 @@ type AppContainer struct {
 
 func NewAppContainer(ctx context.Context, cfg *config.Config) *AppContainer {
 	infra := &InfraContainer{
-		HTTPClient: http_client.NewHTTPClient(cfg.FiatExternalAPI.Timeout),
-		Cache:      cache.NewInMemoryCache(cfg.Cache.TTL),
+		FiatHTTPClient:   newProviderHTTPClient("fiat", cfg.FiatExternalAPI),
+		CryptoHTTPClient: newProviderHTTPClient("crypto", cfg.CryptoExternalAPI),
+		Cache:            newCache(cfg),
+		CurrencyRegistry: newCurrencyRegistry(ctx, cfg),
 	}
 
-	fiatRepo := api.NewExternalAPIRepository(
-		infra.HTTPClient,
+	fiatRepo := withCircuitBreaker(api.NewExternalAPIRepository(
+		infra.FiatHTTPClient,
 		cfg.FiatExternalAPI.BaseURL,
 		cfg.FiatExternalAPI.Secret,
-	)
-	cryptoRepo := api.NewCryptoAPIRepository(
-		infra.HTTPClient,
+	), cfg.FiatExternalAPI.Breaker)
+	cryptoRepo := withCircuitBreaker(api.NewCryptoAPIRepository(
+		infra.CryptoHTTPClient,
 		cfg.CryptoExternalAPI.BaseURL,
 		cfg.CryptoExternalAPI.Secret,
-	)
-	mockRepository := mock.NewMockExchangeRateRepository()
+	), cfg.CryptoExternalAPI.Breaker)
+	mockRepository := mock.NewMockExchangeRateRepository(infra.CurrencyRegistry)
+
+	inMemoryRepository := inmemory.NewInMemoryRepository(infra.Cache, infra.CurrencyRegistry, inmemory.TTLPolicy{
+		Latest:       cfg.Cache.TTL,
+		CryptoLatest: cfg.Cache.CryptoTTL,
+		Historical:   cfg.Cache.HistoricalTTL,
+	})
 
 	repos := &RepositoryContainer{
-		ExternalAPIRepository: api.NewCompositeRepository(fiatRepo, cryptoRepo, mockRepository),
-		InMemoryRepository:    inmemory.NewInMemoryRepository(infra.Cache),
+		ExternalAPIRepository: newCompositeRepository(cfg, infra.CurrencyRegistry, fiatRepo, cryptoRepo, mockRepository),
+		InMemoryRepository:    inMemoryRepository,
+		HistoryRepository:     newHistoryRepository(ctx, cfg),
 		MockRepository:        mockRepository,
 	}
 
+	roundingMode, err := domain_exchange.ParseRoundingMode(cfg.Money.RoundingMode)
+	if err != nil {
+		logger.Warnf("%v, falling back to %s", err, roundingMode)
+	}
+
+	if cfg.Pivot.Currency != "" {
+		if _, exists := infra.CurrencyRegistry.Get(cfg.Pivot.Currency); !exists {
+			logger.Fatalf("Pivot currency %s is not a supported currency", cfg.Pivot.Currency)
+		}
+		logger.Infof("Deriving cross rates through pivot currency %s", cfg.Pivot.Currency)
+	}
+
 	useCases := &UseCaseContainer{
 		ExchangeRateUseCase: usecase.NewExchangeRateUseCase(
 			repos.ExternalAPIRepository,
 			repos.InMemoryRepository,
-			cfg.Cache.MaxHistoricalDays,
+			repos.HistoryRepository,
+			infra.CurrencyRegistry,
+			usecase.Options{
+				MaxHistoricalDays:    cfg.Cache.MaxHistoricalDays,
+				RoundingMode:         roundingMode,
+				MaxStaleAge:          cfg.Cache.MaxStaleAge,
+				LatestTTL:            cfg.Cache.TTL,
+				CryptoLatestTTL:      cfg.Cache.CryptoTTL,
+				RefreshConcurrency:   cfg.Cache.RefreshConcurrency,
+				RefreshTimeout:       cfg.Cache.RefreshTimeout,
+				PivotCurrency:        cfg.Pivot.Currency,
+				CompareDirect:        cfg.Pivot.CompareDirect,
+				PivotMaxDeviationBps: cfg.Pivot.MaxDeviationBps,
+				MaxBatchSize:         cfg.Money.MaxBatchSize,
+			},
 		),
 	}
 
 	handlers := &HandlerContainer{
-		ExchangeRateHandler: handler.NewExchangeRateHandler(useCases.ExchangeRateUseCase),
+		ExchangeRateHandler: handler.NewExchangeRateHandler(useCases.ExchangeRateUseCase, handler.Options{
+			MaxBatchSize:  cfg.Money.MaxBatchSize,
+			MaxBatchBytes: int64(cfg.Money.MaxBatchBytes),
+		}),
 	}
 
 	app := &AppContainer{
@@ -90,3 +138,147 @@ func NewAppContainer(ctx context.Context, cfg *config.Config) *AppContainer {
 
 	return app
 }
+
+// Close releases resources held by the container once the server has stopped.
+func (a *AppContainer) Close() {
+	if err := a.Infra.Cache.Close(); err != nil {
+		logger.Errorf("Failed to close cache: %v", err)
+	}
+	if closer, ok := a.Repositories.HistoryRepository.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logger.Errorf("Failed to close history store: %v", err)
+		}
+	}
+}
+
+// newProviderHTTPClient gives each upstream its own timeout and retry policy.
+func newProviderHTTPClient(provider string, cfg config.ExternalAPIConfig) http_client.HTTPClient {
+	return http_client.NewRetryingHTTPClient(
+		http_client.NewHTTPClient(cfg.Timeout),
+		provider,
+		http_client.RetryPolicy{
+			Attempts:  cfg.RetryAttempts,
+			BaseDelay: cfg.RetryDelay,
+		},
+	)
+}
+
+// newCompositeRepository builds the per-type provider chains from the names
+// listed in the configuration.
+func newCompositeRepository(
+	cfg *config.Config,
+	currencyRegistry domain_exchange.CurrencyRegistry,
+	providers ...domain_exchange.ExchangeRateExternalRepository,
+) domain_exchange.ExchangeRateExternalRepository {
+	byName := make(map[string]domain_exchange.ExchangeRateExternalRepository, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+
+	chain := func(currencyType string, names []string) []domain_exchange.ExchangeRateExternalRepository {
+		repos := make([]domain_exchange.ExchangeRateExternalRepository, 0, len(names))
+		for _, name := range names {
+			provider, exists := byName[name]
+			if !exists {
+				logger.Fatalf("Unknown %s provider %q", currencyType, name)
+			}
+			repos = append(repos, provider)
+		}
+		return repos
+	}
+
+	chains := api.ProviderChains{
+		domain_exchange.CurrencyTypeFiat:   chain(domain_exchange.CurrencyTypeFiat, cfg.Providers.Fiat),
+		domain_exchange.CurrencyTypeCrypto: chain(domain_exchange.CurrencyTypeCrypto, cfg.Providers.Crypto),
+		domain_exchange.CurrencyTypeMetal:  chain(domain_exchange.CurrencyTypeMetal, cfg.Providers.Metal),
+	}
+	if len(chains[domain_exchange.CurrencyTypeFiat]) == 0 {
+		logger.Fatalf("At least one fiat provider must be configured")
+	}
+
+	var consensus bool
+	switch cfg.Providers.Mode {
+	case "failover", "":
+	case "consensus":
+		consensus = true
+	default:
+		logger.Fatalf("Unknown provider mode %q", cfg.Providers.Mode)
+	}
+	return api.NewCompositeRepository(currencyRegistry, chains, api.CompositeOptions{
+		MaxStaleness:    cfg.Providers.MaxStaleness,
+		Consensus:       consensus,
+		MaxDeviationBps: cfg.Providers.MaxDeviationBps,
+	})
+}
+
+// withCircuitBreaker leaves repo unwrapped when the failure threshold is not
+// positive, which disables the breaker.
+func withCircuitBreaker(repo domain_exchange.ExchangeRateExternalRepository, cfg config.BreakerConfig) domain_exchange.ExchangeRateExternalRepository {
+	if cfg.FailureThreshold <= 0 {
+		return repo
+	}
+	return api.NewCircuitBreakerRepository(repo, api.BreakerPolicy{
+		FailureThreshold: cfg.FailureThreshold,
+		SuccessThreshold: cfg.SuccessThreshold,
+		OpenTimeout:      cfg.OpenTimeout,
+	})
+}
+
+func newCurrencyRegistry(ctx context.Context, cfg *config.Config) domain_exchange.CurrencyRegistry {
+	if cfg.Currencies.RegistryPath == "" {
+		return registry.NewStaticRegistry(domain_exchange.DefaultCurrencies)
+	}
+
+	currencyRegistry, err := registry.NewFileRegistry(ctx, cfg.Currencies.RegistryPath, cfg.Currencies.ReloadInterval)
+	if err != nil {
+		logger.Fatalf("Failed to load currency registry: %v", err)
+	}
+	logger.Infof("Loaded currency registry from %s", cfg.Currencies.RegistryPath)
+	return currencyRegistry
+}
+
+func newCache(cfg *config.Config) cache.Cache {
+	switch cfg.Cache.Backend {
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.Cache.Redis.Addr,
+			Password: cfg.Cache.Redis.Password,
+			DB:       cfg.Cache.Redis.DB,
+		})
+
+		pingCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := client.Ping(pingCtx).Err(); err != nil {
+			logger.Fatalf("Failed to connect to redis at %s: %v", cfg.Cache.Redis.Addr, err)
+		}
+		logger.Infof("Using redis cache at %s", cfg.Cache.Redis.Addr)
+		return cache.NewRedisCache(client, cfg.Cache.Redis.KeyPrefix, cfg.Cache.TTL, inmemory.NewExchangeRateCodec())
+	case "memory", "":
+		return cache.NewInMemoryCache(cfg.Cache.TTL, cache.Limits{
+			MaxEntries: cfg.Cache.MaxEntries,
+			MaxBytes:   cfg.Cache.MaxBytes,
+		})
+	default:
+		logger.Fatalf("Unknown cache backend %q", cfg.Cache.Backend)
+		return nil
+	}
+}
+
+// newHistoryRepository returns nil when no history store is configured, in
+// which case historical tables live only in the cache.
+func newHistoryRepository(ctx context.Context, cfg *config.Config) domain_exchange.ExchangeRateCacheRepository {
+	if cfg.History.Driver == "" {
+		return nil
+	}
+
+	db, err := sqlstore.Open(cfg.History.Driver, cfg.History.DSN)
+	if err != nil {
+		logger.Fatalf("Failed to open history store: %v", err)
+	}
+	historyRepo, err := sqlstore.NewSQLRepository(ctx, db)
+	if err != nil {
+		logger.Fatalf("Failed to initialize history store: %v", err)
+	}
+	logger.Infof("Using %s history store", cfg.History.Driver)
+	return historyRepo
+}
@@ -6,7 +6,12 @@ type Config struct {
 	Server            ServerConfig
 	FiatExternalAPI   ExternalAPIConfig
 	CryptoExternalAPI ExternalAPIConfig
+	Providers         ProvidersConfig
+	Pivot             PivotConfig
 	Cache             CacheConfig
+	Money             MoneyConfig
+	Currencies        CurrencyConfig
+	History           HistoryConfig
 }
 
 type ServerConfig struct {
@@ -22,10 +27,68 @@ type ExternalAPIConfig struct {
 	Secret        string
 	RetryAttempts int
 	RetryDelay    time.Duration
+	Breaker       BreakerConfig
+}
+
+type BreakerConfig struct {
+	FailureThreshold int
+	SuccessThreshold int
+	OpenTimeout      time.Duration
+}
+
+// ProvidersConfig lists provider names per currency type in failover order.
+type ProvidersConfig struct {
+	Fiat            []string
+	Crypto          []string
+	Metal           []string
+	MaxStaleness    time.Duration
+	Mode            string
+	MaxDeviationBps float64
 }
 
 type CacheConfig struct {
-	TTL               time.Duration
-	RefreshInterval   time.Duration
-	MaxHistoricalDays int
+	Backend            string
+	TTL                time.Duration
+	CryptoTTL          time.Duration
+	HistoricalTTL      time.Duration
+	MaxStaleAge        time.Duration
+	MaxEntries         int
+	MaxBytes           int64
+	RefreshInterval    time.Duration
+	RefreshConcurrency int
+	RefreshTimeout     time.Duration
+	MaxHistoricalDays  int
+	Redis              RedisConfig
+}
+
+type RedisConfig struct {
+	Addr      string
+	Password  string
+	DB        int
+	KeyPrefix string
+}
+
+// PivotConfig enables deriving cross rates from a single pivot table.
+type PivotConfig struct {
+	Currency        string
+	CompareDirect   bool
+	MaxDeviationBps float64
+}
+
+type MoneyConfig struct {
+	RoundingMode string
+	// MaxBatchSize caps the items of a bulk conversion request and
+	// MaxBatchBytes the size of its body.
+	MaxBatchSize  int
+	MaxBatchBytes int
+}
+
+type CurrencyConfig struct {
+	RegistryPath   string
+	ReloadInterval time.Duration
+}
+
+type HistoryConfig struct {
+	Driver string
+	DSN    string
 }
@@ -1,77 +1,259 @@
 package domain_exchange
 
 import (
-	"errors"
-	"fmt"
+	"strings"
 	"time"
 )
 
+const (
+	CurrencyTypeFiat   = "fiat"
+	CurrencyTypeCrypto = "crypto"
+	CurrencyTypeMetal  = "metal"
+)
+
 type Currency struct {
-	Code   string `json:"code"`
-	Name   string `json:"name"`
-	Symbol string `json:"symbol"`
-	Type   string `json:"type"`
+	Code       string `json:"code"`
+	Numeric    string `json:"numeric,omitempty"`
+	Name       string `json:"name"`
+	Symbol     string `json:"symbol"`
+	Type       string `json:"type"`
+	MinorUnits int32  `json:"minor_units"`
+	Enabled    bool   `json:"enabled"`
 }
 
-var SupportedCurrencies = map[string]Currency{
+// DefaultCurrencies is the catalogue served when no registry file is configured.
+var DefaultCurrencies = map[string]Currency{
 	"USD": {
-		Code:   "USD",
-		Name:   "United States Dollar",
-		Symbol: "$",
-		Type:   "fiat",
+		Code:       "USD",
+		Numeric:    "840",
+		Name:       "United States Dollar",
+		Symbol:     "$",
+		Type:       CurrencyTypeFiat,
+		MinorUnits: 2,
+		Enabled:    true,
 	},
 	"INR": {
-		Code:   "INR",
-		Name:   "Indian Rupee",
-		Symbol: "₹",
-		Type:   "fiat",
+		Code:       "INR",
+		Numeric:    "356",
+		Name:       "Indian Rupee",
+		Symbol:     "₹",
+		Type:       CurrencyTypeFiat,
+		MinorUnits: 2,
+		Enabled:    true,
 	},
 	"EUR": {
-		Code:   "EUR",
-		Name:   "Euro",
-		Symbol: "€",
-		Type:   "fiat",
+		Code:       "EUR",
+		Numeric:    "978",
+		Name:       "Euro",
+		Symbol:     "€",
+		Type:       CurrencyTypeFiat,
+		MinorUnits: 2,
+		Enabled:    true,
 	},
 	"JPY": {
-		Code:   "JPY",
-		Name:   "Japanese Yen",
-		Symbol: "¥",
-		Type:   "fiat",
+		Code:       "JPY",
+		Numeric:    "392",
+		Name:       "Japanese Yen",
+		Symbol:     "¥",
+		Type:       CurrencyTypeFiat,
+		MinorUnits: 0,
+		Enabled:    true,
 	},
 	"GBP": {
-		Code:   "GBP",
-		Name:   "British Pound Sterling",
-		Symbol: "£",
-		Type:   "fiat",
+		Code:       "GBP",
+		Numeric:    "826",
+		Name:       "British Pound Sterling",
+		Symbol:     "£",
+		Type:       CurrencyTypeFiat,
+		MinorUnits: 2,
+		Enabled:    true,
 	},
 
 	"BTC": {
-		Code:   "BTC",
-		Name:   "Bitcoin",
-		Symbol: "BTC",
-		Type:   "crypto",
+		Code:       "BTC",
+		Name:       "Bitcoin",
+		Symbol:     "BTC",
+		Type:       CurrencyTypeCrypto,
+		MinorUnits: 8,
+		Enabled:    true,
 	},
 }
 
+type CurrencyInfo struct {
+	Currency
+	Provider string `json:"provider"`
+	// OldestAllowedDate is the oldest day historical requests may ask for
+	// under the MaxHistoricalDays policy, not what any store holds.
+	OldestAllowedDate time.Time `json:"oldest_allowed_date"`
+}
+
 type ExchangeRate struct {
 	Result          string             `json:"result"`
 	BaseCode        string             `json:"base_code"`
 	ConversionRates map[string]float64 `json:"conversion_rates"`
 	FetchedAt       time.Time          `json:"-"`
+	// Date is the UTC day the table is effective for: the requested day for
+	// historical tables and the day it was fetched for latest tables.
+	Date     time.Time `json:"-"`
+	Provider string    `json:"-"`
+	// Stale marks a latest table served past its freshness while a refresh
+	// is pending or the upstream is unavailable.
+	Stale bool `json:"-"`
+	// Pivot is set on tables derived from the table of another base.
+	Pivot string `json:"-"`
 }
 
-func (c *Currency) ValidateCurrencies(from, to string) error {
-	if from == "" || to == "" {
-		return errors.New("from and to currencies are required")
-	}
+// Age is how long ago the table was fetched from its provider.
+func (r *ExchangeRate) Age() time.Duration {
+	return time.Since(r.FetchedAt)
+}
 
-	if _, exists := SupportedCurrencies[from]; !exists {
-		return fmt.Errorf("currency %s is not supported", from)
+// Size approximates the memory held by the table for cache limits.
+func (r *ExchangeRate) Size() int64 {
+	size := int64(len(r.Result)+len(r.BaseCode)+len(r.Provider)) + 96
+	for code := range r.ConversionRates {
+		size += int64(len(code)) + 48
 	}
+	return size
+}
 
-	if _, exists := SupportedCurrencies[to]; !exists {
-		return fmt.Errorf("currency %s is not supported", to)
-	}
+// ConversionPath lists the currencies a conversion went through, from the
+// source to the target currency.
+type ConversionPath []string
+
+func (p ConversionPath) String() string {
+	return strings.Join(p, "->")
+}
+
+// PairRate is the latest rate of one pair. Date and FetchedAt describe the
+// oldest table on the path.
+type PairRate struct {
+	From      string
+	To        string
+	Rate      float64
+	Path      ConversionPath
+	Date      time.Time
+	FetchedAt time.Time
+	Provider  string
+	Stale     bool
+}
+
+// ConversionRequest asks for Amount of From in To at two dates. A zero date
+// converts at the latest rates.
+type ConversionRequest struct {
+	From     string
+	To       string
+	Amount   Decimal
+	FromDate time.Time
+	ToDate   time.Time
+}
+
+// Conversion is the result of a ConversionRequest. FromDate and ToDate are
+// the days the rates are effective for, which for latest rates is the day
+// they were fetched.
+type Conversion struct {
+	From            string         `json:"from"`
+	To              string         `json:"to"`
+	OriginalAmount  Decimal        `json:"original_amount"`
+	ConvertedAtFrom Decimal        `json:"converted_at_from"`
+	ConvertedAtTo   Decimal        `json:"converted_at_to"`
+	FromRate        Decimal        `json:"from_rate"`
+	ToRate          Decimal        `json:"to_rate"`
+	FromDate        time.Time      `json:"from_date"`
+	ToDate          time.Time      `json:"to_date"`
+	Provider        string         `json:"provider"`
+	Stale           bool           `json:"stale"`
+	Path            ConversionPath `json:"path"`
+}
+
+// BatchConversionItem is one line of a bulk conversion. A zero Date converts
+// at the latest rates.
+type BatchConversionItem struct {
+	From   string
+	To     string
+	Amount Decimal
+	Date   time.Time
+}
+
+// BatchConversionResult is the outcome of one BatchConversionItem; Err is set
+// instead of the amounts when that item could not be converted.
+type BatchConversionResult struct {
+	Converted Decimal
+	Rate      Decimal
+	Path      ConversionPath
+	Err       error
+}
+
+// RateMatrix holds the cross rates between a set of currencies, the latest
+// ones when Date is zero. Rates[from][to] is the from→to rate; pairs without
+// a rate are listed in Missing as "FROM/TO".
+type RateMatrix struct {
+	Currencies []string
+	Date       time.Time
+	Rates      map[string]map[string]float64
+	Missing    []string
+	// Inconsistencies and Unchecked are only filled when inverse checks were
+	// requested. Unchecked lists the pairs derived from the pivot table that
+	// have no direct quote to be checked against.
+	Inconsistencies []RateInconsistency
+	Unchecked       []string
+}
+
+// RateInconsistency reports a pair whose rate and inverse rate do not
+// multiply to one.
+type RateInconsistency struct {
+	From         string
+	To           string
+	DeviationBps float64
+}
+
+// StartOfDay returns midnight UTC of the day t falls on in UTC.
+func StartOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+type RatePoint struct {
+	Date time.Time `json:"date"`
+	Rate float64   `json:"rate"`
+}
+
+type TimeSeries struct {
+	From        string      `json:"from"`
+	To          string      `json:"to"`
+	Start       time.Time   `json:"start"`
+	End         time.Time   `json:"end"`
+	Points      []RatePoint `json:"points"`
+	MissingDays []time.Time `json:"missing_days"`
+}
+
+// RateChange summarises how a rate moved over a range of days. StartDate and
+// EndDate are the first and last days that had a rate; Volatility is the
+// sample standard deviation of the returns between consecutive such days.
+type RateChange struct {
+	From           string
+	To             string
+	StartDate      time.Time
+	EndDate        time.Time
+	StartRate      float64
+	EndRate        float64
+	AbsoluteChange float64
+	PercentChange  float64
+	Min            float64
+	Max            float64
+	Mean           float64
+	Volatility     float64
+	Points         int
+	MissingDays    []time.Time
+}
+
+// RefreshResult reports which base tables a refresh run managed to update.
+type RefreshResult struct {
+	Succeeded []string
+	Failed    []RefreshFailure
+}
 
-	return nil
+type RefreshFailure struct {
+	Currency string
+	Err      error
 }
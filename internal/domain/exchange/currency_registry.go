@@ -0,0 +1,8 @@
+package domain_exchange
+
+type CurrencyRegistry interface {
+	// Get returns the currency only when it is known and enabled.
+	Get(code string) (Currency, bool)
+	// List returns every enabled currency ordered by code.
+	List() []Currency
+}
@@ -0,0 +1,42 @@
+package domain_exchange
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error kinds reported by the exchange use case. Callers classify failures
+// with errors.Is; the messages stay free for humans.
+var (
+	ErrInvalidRequest      = errors.New("invalid request")
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	// ErrCurrencyNotFound is returned when a currency is looked up by code
+	// rather than used in a conversion.
+	ErrCurrencyNotFound = errors.New("currency not found")
+	ErrDateOutOfRange   = errors.New("date out of range")
+	ErrRateUnavailable  = errors.New("rate unavailable")
+	ErrUpstreamFailure  = errors.New("upstream provider failure")
+	ErrRateLimited      = errors.New("rate limited by upstream provider")
+	// ErrCircuitOpen is returned by external repositories that refuse to call
+	// an upstream provider while its circuit breaker is open.
+	ErrCircuitOpen = errors.New("upstream provider circuit breaker is open")
+)
+
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// Errorf formats an error like fmt.Errorf and tags it with kind, so that
+// errors.Is(err, kind) holds without kind showing up in the message.
+func Errorf(kind error, format string, args ...any) error {
+	return &kindError{kind: kind, err: fmt.Errorf(format, args...)}
+}
@@ -8,11 +8,18 @@ import (
 // TODO : Currently used by both infra and domain layer. Seperation needed
 
 type ExchangeRateExternalRepository interface {
+	Name() string
 	GetLatestRate(ctx context.Context, fromCurrency string) (*ExchangeRate, error)
 	GetRateByDate(ctx context.Context, fromCurrency, toCurrency string, date time.Time) (*ExchangeRate, error)
 	GetRatesForDateRange(ctx context.Context, fromCurrency, toCurrency string, startDate, endDate time.Time) ([]*ExchangeRate, error)
 }
 
+// ProviderResolver is implemented by external repositories that route
+// currencies to different upstream providers.
+type ProviderResolver interface {
+	ProviderFor(currency string) string
+}
+
 type ExchangeRateCacheRepository interface {
 	StoreRate(ctx context.Context, rate *ExchangeRate) error
 	GetCachedRate(ctx context.Context, fromCurrency, toCurrency string, date time.Time) (*ExchangeRate, error)
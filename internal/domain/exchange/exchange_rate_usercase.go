@@ -6,9 +6,16 @@ import (
 )
 
 type ExchangeRateUsercase interface {
-	ConvertAmount(ctx context.Context, from, to string, amount float64, fromDate, toDate time.Time) (float64, float64, float64, float64, error)
-	GetLatestRate(ctx context.Context, from, to string) (float64, error)
-	RefreshRates(ctx context.Context) error
+	ConvertAmount(ctx context.Context, request ConversionRequest) (*Conversion, error)
+	ConvertBatch(ctx context.Context, items []BatchConversionItem) ([]BatchConversionResult, error)
+	GetLatestRate(ctx context.Context, from, to string) (*PairRate, error)
+	GetLatestRates(ctx context.Context, from string) (*ExchangeRate, error)
+	GetRateChange(ctx context.Context, from, to string, start, end time.Time) (*RateChange, error)
+	GetRateMatrix(ctx context.Context, currencies []string, date time.Time, checkInverse bool) (*RateMatrix, error)
+	GetTimeSeries(ctx context.Context, from, to string, start, end time.Time) (*TimeSeries, error)
+	ListCurrencies(currencyType string) ([]CurrencyInfo, error)
+	GetCurrency(code string) (*CurrencyInfo, error)
+	RefreshRates(ctx context.Context) (*RefreshResult, error)
 	ValidateCurrencies(from, to string) error
 	ValidateDate(date time.Time, maxHistoricalDays int) error
 }
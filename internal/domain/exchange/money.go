@@ -0,0 +1,177 @@
+package domain_exchange
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+type RoundingMode int
+
+const (
+	RoundHalfEven RoundingMode = iota
+	RoundHalfUp
+	RoundDown
+)
+
+func ParseRoundingMode(mode string) (RoundingMode, error) {
+	switch strings.ToLower(mode) {
+	case "half-even", "":
+		return RoundHalfEven, nil
+	case "half-up":
+		return RoundHalfUp, nil
+	case "down":
+		return RoundDown, nil
+	}
+	return RoundHalfEven, fmt.Errorf("unknown rounding mode %q", mode)
+}
+
+func (m RoundingMode) String() string {
+	switch m {
+	case RoundHalfUp:
+		return "half-up"
+	case RoundDown:
+		return "down"
+	}
+	return "half-even"
+}
+
+// Decimal is an exact base-10 number: value * 10^-scale. The zero value is 0.
+type Decimal struct {
+	value *big.Int
+	scale int32
+}
+
+var bigTen = big.NewInt(10)
+
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	if digits == "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	intPart, fracPart, _ := strings.Cut(digits, ".")
+	if intPart == "" && fracPart == "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	for _, r := range intPart + fracPart {
+		if r < '0' || r > '9' {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+	}
+
+	value, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	if strings.HasPrefix(s, "-") {
+		value.Neg(value)
+	}
+	return Decimal{value: value, scale: int32(len(fracPart))}, nil
+}
+
+// NewDecimalFromFloat uses the shortest representation that round-trips the
+// float, so a published rate of 0.1 becomes exactly 0.1 rather than its binary
+// approximation.
+func NewDecimalFromFloat(f float64) Decimal {
+	d, err := ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		return Decimal{}
+	}
+	return d
+}
+
+func (d Decimal) unscaled() *big.Int {
+	if d.value == nil {
+		return new(big.Int)
+	}
+	return d.value
+}
+
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+func (d Decimal) Sign() int {
+	return d.unscaled().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Equal compares values regardless of scale, so 10 equals 10.00.
+func (d Decimal) Equal(other Decimal) bool {
+	scale := max(d.scale, other.scale)
+	return d.Round(scale, RoundDown).unscaled().Cmp(other.Round(scale, RoundDown).unscaled()) == 0
+}
+
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{
+		value: new(big.Int).Mul(d.unscaled(), other.unscaled()),
+		scale: d.scale + other.scale,
+	}
+}
+
+// Round returns d with exactly scale fractional digits.
+func (d Decimal) Round(scale int32, mode RoundingMode) Decimal {
+	if d.scale <= scale {
+		factor := new(big.Int).Exp(bigTen, big.NewInt(int64(scale-d.scale)), nil)
+		return Decimal{value: new(big.Int).Mul(d.unscaled(), factor), scale: scale}
+	}
+
+	divisor := new(big.Int).Exp(bigTen, big.NewInt(int64(d.scale-scale)), nil)
+	quotient, remainder := new(big.Int).QuoRem(d.unscaled(), divisor, new(big.Int))
+	if remainder.Sign() != 0 && mode != RoundDown {
+		twice := new(big.Int).Abs(remainder)
+		twice.Lsh(twice, 1)
+		cmp := twice.Cmp(divisor)
+		if cmp > 0 || (cmp == 0 && (mode == RoundHalfUp || quotient.Bit(0) == 1)) {
+			if d.Sign() < 0 {
+				quotient.Sub(quotient, big.NewInt(1))
+			} else {
+				quotient.Add(quotient, big.NewInt(1))
+			}
+		}
+	}
+	return Decimal{value: quotient, scale: scale}
+}
+
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.unscaled()).String()
+	if d.scale > 0 {
+		if pad := int(d.scale) - len(digits) + 1; pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		point := len(digits) - int(d.scale)
+		digits = digits[:point] + "." + digits[point:]
+	}
+	if d.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
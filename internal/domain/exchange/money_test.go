@@ -0,0 +1,98 @@
+package domain_exchange
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"10", "10", true},
+		{"10.50", "10.50", true},
+		{"-0.005", "-0.005", true},
+		{".5", "0.5", true},
+		{"", "", false},
+		{"1e3", "", false},
+		{"1.2.3", "", false},
+		{"abc", "", false},
+	}
+	for _, tt := range tests {
+		d, err := ParseDecimal(tt.in)
+		if (err == nil) != tt.ok {
+			t.Fatalf("ParseDecimal(%q) error = %v, want ok=%v", tt.in, err, tt.ok)
+		}
+		if tt.ok && d.String() != tt.want {
+			t.Fatalf("ParseDecimal(%q) = %s, want %s", tt.in, d, tt.want)
+		}
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		in    string
+		scale int32
+		mode  RoundingMode
+		want  string
+	}{
+		{"2.345", 2, RoundHalfEven, "2.34"},
+		{"2.355", 2, RoundHalfEven, "2.36"},
+		{"2.345", 2, RoundHalfUp, "2.35"},
+		{"2.349", 2, RoundDown, "2.34"},
+		{"-2.345", 2, RoundHalfUp, "-2.35"},
+		{"-2.349", 2, RoundDown, "-2.34"},
+		{"1500.5", 0, RoundHalfEven, "1500"},
+		{"1501.5", 0, RoundHalfEven, "1502"},
+		{"0.1", 8, RoundHalfEven, "0.10000000"},
+	}
+	for _, tt := range tests {
+		d, err := ParseDecimal(tt.in)
+		if err != nil {
+			t.Fatalf("ParseDecimal(%q): %v", tt.in, err)
+		}
+		if got := d.Round(tt.scale, tt.mode).String(); got != tt.want {
+			t.Fatalf("Round(%s, %d, %s) = %s, want %s", tt.in, tt.scale, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalMulIsExact(t *testing.T) {
+	amount, _ := ParseDecimal("0.1")
+	rate := NewDecimalFromFloat(0.2)
+
+	if got := amount.Mul(rate).String(); got != "0.02" {
+		t.Fatalf("0.1 * 0.2 = %s, want 0.02", got)
+	}
+}
+
+func TestDecimalEqualIgnoresTrailingZeros(t *testing.T) {
+	a, _ := ParseDecimal("10")
+	b, _ := ParseDecimal("10.000")
+	c, _ := ParseDecimal("10.001")
+
+	if !a.Equal(b) || a.Equal(c) {
+		t.Fatalf("expected 10 == 10.000 and 10 != 10.001")
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	d, _ := ParseDecimal("12.30")
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(data) != `"12.30"` {
+		t.Fatalf("marshal = %s, want \"12.30\"", data)
+	}
+
+	var decoded Decimal
+	if err := json.Unmarshal([]byte(`12.5`), &decoded); err != nil {
+		t.Fatalf("unmarshal number: %v", err)
+	}
+	if decoded.String() != "12.5" {
+		t.Fatalf("unmarshal = %s, want 12.5", decoded)
+	}
+}
@@ -0,0 +1,144 @@
+package http_client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"exchange-rate-service/pkg/logger"
+	"exchange-rate-service/pkg/metrics"
+)
+
+// defaultMaxRetryDelay caps the exponential backoff so a long run of
+// failures does not push a single wait past what a caller would tolerate.
+const defaultMaxRetryDelay = 30 * time.Second
+
+// RetryPolicy configures the retrying client. Attempts is the number of
+// retries made after the initial request; zero disables retrying.
+type RetryPolicy struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+type retryingHTTPClient struct {
+	next     HTTPClient
+	provider string
+	policy   RetryPolicy
+}
+
+// NewRetryingHTTPClient wraps next so GET requests are retried on network
+// errors, 429 and 5xx responses with exponential backoff and jitter. POST
+// requests are passed through untouched because they are not idempotent.
+func NewRetryingHTTPClient(next HTTPClient, provider string, policy RetryPolicy) HTTPClient {
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = defaultMaxRetryDelay
+	}
+	return &retryingHTTPClient{
+		next:     next,
+		provider: provider,
+		policy:   policy,
+	}
+}
+
+func (c *retryingHTTPClient) Get(ctx context.Context, url string, headers map[string]string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.next.Get(ctx, url, headers)
+
+		reason := retryReason(resp, err)
+		if reason == "" || attempt >= c.policy.Attempts || ctx.Err() != nil {
+			return resp, err
+		}
+
+		delay := c.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if retryAfter > c.policy.MaxDelay {
+					// The upstream asked for a longer pause than we are willing
+					// to hold the request for, so surface its answer instead.
+					return resp, err
+				}
+				if retryAfter > delay {
+					delay = retryAfter
+				}
+			}
+			// Drain the body so the connection can be reused by the next attempt.
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		metrics.ExternalAPIRetries.WithLabelValues(c.provider, reason).Inc()
+		logger.Warnf("%s request failed (%s), retrying in %v (attempt %d of %d)",
+			c.provider, reason, delay, attempt+1, c.policy.Attempts)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("retry of %s request cancelled: %w", c.provider, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *retryingHTTPClient) Post(ctx context.Context, url string, body any, headers map[string]string) (*http.Response, error) {
+	return c.next.Post(ctx, url, body, headers)
+}
+
+// backoff doubles the base delay per attempt and picks a random wait in the
+// upper half of it so clients that failed together do not retry together.
+func (c *retryingHTTPClient) backoff(attempt int) time.Duration {
+	delay := c.policy.BaseDelay
+	for i := 0; i < attempt && delay < c.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > c.policy.MaxDelay {
+		delay = c.policy.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// retryReason returns why the outcome should be retried, or "" when it
+// should be returned to the caller as is.
+func retryReason(resp *http.Response, err error) string {
+	switch {
+	case err != nil:
+		return "network_error"
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return "rate_limited"
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return "server_error"
+	default:
+		return ""
+	}
+}
+
+// parseRetryAfter accepts both forms allowed by RFC 9110: a number of
+// seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,195 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+	"exchange-rate-service/pkg/logger"
+
+	"gopkg.in/yaml.v3"
+)
+
+type currencyRegistry struct {
+	currencies map[string]domain_exchange.Currency
+	mutex      sync.RWMutex
+}
+
+func NewStaticRegistry(currencies map[string]domain_exchange.Currency) domain_exchange.CurrencyRegistry {
+	r := &currencyRegistry{}
+	r.replace(currencies)
+	return r
+}
+
+func (r *currencyRegistry) Get(code string) (domain_exchange.Currency, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	currency, exists := r.currencies[code]
+	if !exists || !currency.Enabled {
+		return domain_exchange.Currency{}, false
+	}
+	return currency, true
+}
+
+func (r *currencyRegistry) List() []domain_exchange.Currency {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	currencies := make([]domain_exchange.Currency, 0, len(r.currencies))
+	for _, currency := range r.currencies {
+		if currency.Enabled {
+			currencies = append(currencies, currency)
+		}
+	}
+	sort.Slice(currencies, func(i, j int) bool {
+		return currencies[i].Code < currencies[j].Code
+	})
+	return currencies
+}
+
+func (r *currencyRegistry) replace(currencies map[string]domain_exchange.Currency) {
+	copied := make(map[string]domain_exchange.Currency, len(currencies))
+	for code, currency := range currencies {
+		copied[code] = currency
+	}
+
+	r.mutex.Lock()
+	r.currencies = copied
+	r.mutex.Unlock()
+}
+
+type fileRegistry struct {
+	*currencyRegistry
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// NewFileRegistry loads the catalogue from a YAML or JSON file and polls it
+// for changes every reloadInterval until ctx is cancelled. A file that fails
+// to parse on reload is logged and the previous catalogue is kept.
+func NewFileRegistry(ctx context.Context, path string, reloadInterval time.Duration) (domain_exchange.CurrencyRegistry, error) {
+	r := &fileRegistry{
+		currencyRegistry: &currencyRegistry{},
+		path:             path,
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	if reloadInterval > 0 {
+		go r.watch(ctx, reloadInterval)
+	}
+	return r, nil
+}
+
+func (r *fileRegistry) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(r.path)
+			if err != nil {
+				logger.Errorf("Failed to stat currency registry %s: %v", r.path, err)
+				continue
+			}
+			if info.ModTime().Equal(r.modTime) && info.Size() == r.size {
+				continue
+			}
+			if err := r.load(); err != nil {
+				logger.Errorf("Failed to reload currency registry, keeping previous: %v", err)
+				continue
+			}
+			logger.Infof("Reloaded currency registry from %s", r.path)
+		}
+	}
+}
+
+type currencyFile struct {
+	Currencies []currencyFileEntry `json:"currencies" yaml:"currencies"`
+}
+
+type currencyFileEntry struct {
+	Code       string `json:"code" yaml:"code"`
+	Numeric    string `json:"numeric" yaml:"numeric"`
+	Name       string `json:"name" yaml:"name"`
+	Symbol     string `json:"symbol" yaml:"symbol"`
+	Type       string `json:"type" yaml:"type"`
+	MinorUnits int32  `json:"minor_units" yaml:"minor_units"`
+	Enabled    *bool  `json:"enabled" yaml:"enabled"`
+}
+
+func (r *fileRegistry) load() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat currency registry: %w", err)
+	}
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to read currency registry: %w", err)
+	}
+
+	var file currencyFile
+	if strings.EqualFold(filepath.Ext(r.path), ".json") {
+		err = json.Unmarshal(data, &file)
+	} else {
+		err = yaml.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse currency registry %s: %w", r.path, err)
+	}
+
+	currencies, err := parseCurrencies(file.Currencies)
+	if err != nil {
+		return fmt.Errorf("invalid currency registry %s: %w", r.path, err)
+	}
+
+	r.replace(currencies)
+	r.modTime = info.ModTime()
+	r.size = info.Size()
+	return nil
+}
+
+func parseCurrencies(entries []currencyFileEntry) (map[string]domain_exchange.Currency, error) {
+	currencies := make(map[string]domain_exchange.Currency, len(entries))
+	for _, entry := range entries {
+		code := strings.ToUpper(strings.TrimSpace(entry.Code))
+		if code == "" {
+			return nil, fmt.Errorf("currency code is required")
+		}
+		if _, exists := currencies[code]; exists {
+			return nil, fmt.Errorf("currency %s is defined more than once", code)
+		}
+		switch entry.Type {
+		case domain_exchange.CurrencyTypeFiat, domain_exchange.CurrencyTypeCrypto, domain_exchange.CurrencyTypeMetal:
+		default:
+			return nil, fmt.Errorf("currency %s has unknown type %q", code, entry.Type)
+		}
+		if entry.MinorUnits < 0 || entry.MinorUnits > 18 {
+			return nil, fmt.Errorf("currency %s has invalid minor units %d", code, entry.MinorUnits)
+		}
+
+		currencies[code] = domain_exchange.Currency{
+			Code:       code,
+			Numeric:    entry.Numeric,
+			Name:       entry.Name,
+			Symbol:     entry.Symbol,
+			Type:       entry.Type,
+			MinorUnits: entry.MinorUnits,
+			Enabled:    entry.Enabled == nil || *entry.Enabled,
+		}
+	}
+	return currencies, nil
+}
@@ -0,0 +1,87 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const registryYAML = `currencies:
+  - code: USD
+    numeric: "840"
+    name: United States Dollar
+    symbol: $
+    type: fiat
+    minor_units: 2
+  - code: CHF
+    numeric: "756"
+    name: Swiss Franc
+    symbol: CHF
+    type: fiat
+    minor_units: 2
+    enabled: false
+`
+
+func TestFileRegistry_LoadsYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "currencies.yaml")
+	if err := os.WriteFile(path, []byte(registryYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewFileRegistry(context.Background(), path, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	usd, ok := r.Get("USD")
+	if !ok || !usd.Enabled || usd.MinorUnits != 2 {
+		t.Fatalf("expected enabled USD with 2 minor units, got %+v ok=%v", usd, ok)
+	}
+	if _, ok := r.Get("CHF"); ok {
+		t.Fatal("expected disabled CHF to be unsupported")
+	}
+	if got := len(r.List()); got != 1 {
+		t.Fatalf("expected 1 enabled currency, got %d", got)
+	}
+}
+
+func TestFileRegistry_RejectsUnknownType(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "currencies.json")
+	content := `{"currencies":[{"code":"XYZ","type":"stock","minor_units":2}]}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewFileRegistry(context.Background(), path, 0); err == nil {
+		t.Fatal("expected error for unknown currency type")
+	}
+}
+
+func TestFileRegistry_HotReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "currencies.json")
+	if err := os.WriteFile(path, []byte(`{"currencies":[{"code":"USD","type":"fiat","minor_units":2}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, err := NewFileRegistry(ctx, path, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updated := `{"currencies":[{"code":"USD","type":"fiat","minor_units":2},{"code":"ETH","type":"crypto","minor_units":8}]}`
+	if err := os.WriteFile(path, []byte(updated), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok := r.Get("ETH"); ok {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("expected ETH to appear after reload")
+}
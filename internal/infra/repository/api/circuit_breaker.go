@@ -0,0 +1,154 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+	"exchange-rate-service/pkg/logger"
+	"exchange-rate-service/pkg/metrics"
+)
+
+type breakerState int
+
+// The numeric values are exported as the circuit_breaker_state gauge.
+const (
+	breakerClosed breakerState = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerHalfOpen:
+		return "half-open"
+	case breakerOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerPolicy configures a circuit breaker. FailureThreshold consecutive
+// failures open the circuit; after OpenTimeout a single probe request is let
+// through and SuccessThreshold successful probes close it again.
+type BreakerPolicy struct {
+	FailureThreshold int
+	SuccessThreshold int
+	OpenTimeout      time.Duration
+}
+
+type circuitBreaker struct {
+	name   string
+	policy BreakerPolicy
+	now    func() time.Time
+
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	successes int
+	openedAt  time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(name string, policy BreakerPolicy) *circuitBreaker {
+	if policy.SuccessThreshold <= 0 {
+		policy.SuccessThreshold = 1
+	}
+	b := &circuitBreaker{
+		name:   name,
+		policy: policy,
+		now:    time.Now,
+	}
+	metrics.CircuitBreakerState.WithLabelValues(name).Set(float64(breakerClosed))
+	return b
+}
+
+// allow reports whether a request may be sent upstream. In the half-open
+// state only one probe is in flight at a time; everyone else fails fast.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.policy.OpenTimeout {
+			return domain_exchange.ErrCircuitOpen
+		}
+		b.setState(breakerHalfOpen)
+		b.probing = true
+		return nil
+	case breakerHalfOpen:
+		if b.probing {
+			return domain_exchange.ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (b *circuitBreaker) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerHalfOpen:
+		b.probing = false
+		b.successes++
+		if b.successes >= b.policy.SuccessThreshold {
+			b.setState(breakerClosed)
+		}
+	case breakerClosed:
+		b.failures = 0
+	}
+}
+
+func (b *circuitBreaker) recordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerHalfOpen:
+		b.probing = false
+		b.setState(breakerOpen)
+	case breakerClosed:
+		b.failures++
+		if b.failures >= b.policy.FailureThreshold {
+			b.setState(breakerOpen)
+		}
+	}
+}
+
+// release gives up a half-open probe without judging the upstream, used when
+// the caller went away before the provider answered.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.probing = false
+	}
+}
+
+func (b *circuitBreaker) currentState() breakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *circuitBreaker) setState(state breakerState) {
+	if b.state == state {
+		return
+	}
+	logger.Warnf("Circuit breaker for %s changed from %s to %s", b.name, b.state, state)
+
+	b.state = state
+	b.failures = 0
+	b.successes = 0
+	if state == breakerOpen {
+		b.openedAt = b.now()
+	}
+	metrics.CircuitBreakerState.WithLabelValues(b.name).Set(float64(state))
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+// circuitBreakerRepository stops calling a failing provider until it has had
+// time to recover. It keeps no tables of its own: serving stale rates during
+// an outage is left to the use case.
+type circuitBreakerRepository struct {
+	repo    domain_exchange.ExchangeRateExternalRepository
+	breaker *circuitBreaker
+}
+
+func NewCircuitBreakerRepository(repo domain_exchange.ExchangeRateExternalRepository, policy BreakerPolicy) domain_exchange.ExchangeRateExternalRepository {
+	return &circuitBreakerRepository{
+		repo:    repo,
+		breaker: newCircuitBreaker(repo.Name(), policy),
+	}
+}
+
+func (r *circuitBreakerRepository) Name() string {
+	return r.repo.Name()
+}
+
+func (r *circuitBreakerRepository) GetLatestRate(ctx context.Context, fromCurrency string) (*domain_exchange.ExchangeRate, error) {
+	var rate *domain_exchange.ExchangeRate
+	err := r.call(ctx, func() (err error) {
+		rate, err = r.repo.GetLatestRate(ctx, fromCurrency)
+		return err
+	})
+	return rate, err
+}
+
+func (r *circuitBreakerRepository) GetRateByDate(ctx context.Context, fromCurrency, toCurrency string, date time.Time) (*domain_exchange.ExchangeRate, error) {
+	var rate *domain_exchange.ExchangeRate
+	err := r.call(ctx, func() (err error) {
+		rate, err = r.repo.GetRateByDate(ctx, fromCurrency, toCurrency, date)
+		return err
+	})
+	return rate, err
+}
+
+// GetRatesForDateRange sends every day through the breaker, so an outage
+// opens it after a few days instead of being hidden in the provider's loop.
+func (r *circuitBreakerRepository) GetRatesForDateRange(ctx context.Context, fromCurrency, toCurrency string, startDate, endDate time.Time) ([]*domain_exchange.ExchangeRate, error) {
+	return ratesByDay(ctx, startDate, endDate, func(date time.Time) (*domain_exchange.ExchangeRate, error) {
+		return r.GetRateByDate(ctx, fromCurrency, toCurrency, date)
+	})
+}
+
+func (r *circuitBreakerRepository) call(ctx context.Context, fn func() error) error {
+	if err := r.breaker.allow(); err != nil {
+		return fmt.Errorf("%s: %w", r.Name(), err)
+	}
+
+	err := fn()
+	switch {
+	case err == nil:
+		r.breaker.recordSuccess()
+	case ctx.Err() != nil || !isProviderOutage(err):
+		// A cancelled caller or a rejected request says nothing about the
+		// provider's health.
+		r.breaker.release()
+	default:
+		r.breaker.recordFailure()
+	}
+	return err
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+	"exchange-rate-service/internal/infra/registry"
+)
+
+var errUpstreamDown = statusError(http.StatusServiceUnavailable, "upstream down")
+
+type flakyRepo struct {
+	name      string
+	fail      bool
+	fetchedAt time.Time
+	calls     int
+}
+
+func (r *flakyRepo) Name() string {
+	if r.name == "" {
+		return "flaky"
+	}
+	return r.name
+}
+
+func (r *flakyRepo) GetLatestRate(ctx context.Context, fromCurrency string) (*domain_exchange.ExchangeRate, error) {
+	r.calls++
+	if r.fail {
+		return nil, errUpstreamDown
+	}
+	fetchedAt := r.fetchedAt
+	if fetchedAt.IsZero() {
+		fetchedAt = time.Now()
+	}
+	return &domain_exchange.ExchangeRate{
+		BaseCode:        fromCurrency,
+		ConversionRates: map[string]float64{"EUR": 0.9},
+		FetchedAt:       fetchedAt,
+		Provider:        r.Name(),
+	}, nil
+}
+
+func (r *flakyRepo) GetRateByDate(ctx context.Context, fromCurrency, toCurrency string, date time.Time) (*domain_exchange.ExchangeRate, error) {
+	r.calls++
+	if r.fail {
+		return nil, errUpstreamDown
+	}
+	return &domain_exchange.ExchangeRate{BaseCode: fromCurrency, Date: date, Provider: r.Name()}, nil
+}
+
+func (r *flakyRepo) GetRatesForDateRange(ctx context.Context, fromCurrency, toCurrency string, startDate, endDate time.Time) ([]*domain_exchange.ExchangeRate, error) {
+	r.calls++
+	if r.fail {
+		return nil, errUpstreamDown
+	}
+	return []*domain_exchange.ExchangeRate{{BaseCode: fromCurrency, Date: startDate, Provider: r.Name()}}, nil
+}
+
+func newTestBreakerRepo(upstream *flakyRepo, now *time.Time) *circuitBreakerRepository {
+	repo := NewCircuitBreakerRepository(upstream, BreakerPolicy{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+	}).(*circuitBreakerRepository)
+	repo.breaker.now = func() time.Time { return *now }
+	return repo
+}
+
+func TestCircuitBreaker_OpensAfterThresholdAndFailsFast(t *testing.T) {
+	now := time.Now()
+	upstream := &flakyRepo{fail: true}
+	repo := newTestBreakerRepo(upstream, &now)
+	ctx := context.Background()
+	date := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if _, err := repo.GetRateByDate(ctx, "USD", "EUR", date); err == nil {
+			t.Fatal("expected upstream error")
+		}
+	}
+	if state := repo.breaker.currentState(); state != breakerOpen {
+		t.Fatalf("expected open breaker, got %s", state)
+	}
+
+	_, err := repo.GetRateByDate(ctx, "USD", "EUR", date)
+	if !errors.Is(err, domain_exchange.ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if upstream.calls != 2 {
+		t.Fatalf("expected open breaker to skip upstream, got %d calls", upstream.calls)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbeClosesCircuit(t *testing.T) {
+	now := time.Now()
+	upstream := &flakyRepo{fail: true}
+	repo := newTestBreakerRepo(upstream, &now)
+	ctx := context.Background()
+
+	repo.GetLatestRate(ctx, "USD")
+	repo.GetLatestRate(ctx, "USD")
+	if state := repo.breaker.currentState(); state != breakerOpen {
+		t.Fatalf("expected open breaker, got %s", state)
+	}
+
+	// A failed probe re-opens the circuit for another timeout.
+	now = now.Add(2 * time.Minute)
+	repo.GetLatestRate(ctx, "USD")
+	if state := repo.breaker.currentState(); state != breakerOpen {
+		t.Fatalf("expected failed probe to re-open breaker, got %s", state)
+	}
+
+	now = now.Add(2 * time.Minute)
+	upstream.fail = false
+	if _, err := repo.GetLatestRate(ctx, "USD"); err != nil {
+		t.Fatalf("expected probe to succeed: %v", err)
+	}
+	if state := repo.breaker.currentState(); state != breakerClosed {
+		t.Fatalf("expected closed breaker, got %s", state)
+	}
+}
+
+func TestCircuitBreaker_CancelledCallerDoesNotTrip(t *testing.T) {
+	now := time.Now()
+	upstream := &flakyRepo{fail: true}
+	repo := newTestBreakerRepo(upstream, &now)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 3; i++ {
+		repo.GetLatestRate(ctx, "USD")
+	}
+	if state := repo.breaker.currentState(); state != breakerClosed {
+		t.Fatalf("expected cancelled requests to leave breaker closed, got %s", state)
+	}
+}
+
+// rejectingRepo answers every request with a client error.
+type rejectingRepo struct {
+	flakyRepo
+}
+
+func (r *rejectingRepo) GetLatestRate(ctx context.Context, fromCurrency string) (*domain_exchange.ExchangeRate, error) {
+	r.calls++
+	if r.fail {
+		return nil, statusError(http.StatusBadRequest, "unsupported code %s", fromCurrency)
+	}
+	return r.flakyRepo.GetLatestRate(ctx, fromCurrency)
+}
+
+func TestCircuitBreaker_RejectedRequestsDoNotTrip(t *testing.T) {
+	now := time.Now()
+	upstream := &rejectingRepo{}
+	repo := NewCircuitBreakerRepository(upstream, BreakerPolicy{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+	}).(*circuitBreakerRepository)
+	repo.breaker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := repo.GetLatestRate(ctx, "USD"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	upstream.fail = true
+	for i := 0; i < 3; i++ {
+		if _, err := repo.GetLatestRate(ctx, "USD"); err == nil {
+			t.Fatal("expected the client error")
+		}
+	}
+	if state := repo.breaker.currentState(); state != breakerClosed {
+		t.Fatalf("expected client errors to leave breaker closed, got %s", state)
+	}
+}
+
+func TestCircuitBreaker_RangeOutageOpensCircuitAndFailsOver(t *testing.T) {
+	now := time.Now()
+	primary := &flakyRepo{name: "primary", fail: true}
+	breaker := newTestBreakerRepo(primary, &now)
+	secondary := &flakyRepo{name: "secondary"}
+	composite := NewCompositeRepository(registry.NewStaticRegistry(domain_exchange.DefaultCurrencies), ProviderChains{
+		domain_exchange.CurrencyTypeFiat: {breaker, secondary},
+	}, CompositeOptions{MaxStaleness: time.Hour})
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 9)
+
+	if _, err := breaker.GetRatesForDateRange(ctx, "USD", "EUR", start, end); err == nil {
+		t.Fatal("expected the range to fail during an outage")
+	}
+	if _, err := breaker.GetRatesForDateRange(ctx, "USD", "EUR", start, end); err == nil {
+		t.Fatal("expected the range to fail during an outage")
+	}
+	if state := breaker.breaker.currentState(); state != breakerOpen {
+		t.Fatalf("expected failed ranges to open the breaker, got %s", state)
+	}
+
+	rates, err := composite.GetRatesForDateRange(ctx, "USD", "EUR", start, end)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rates) != 1 || rates[0].Provider != "secondary" {
+		t.Fatalf("expected the secondary to answer the range, got %+v", rates)
+	}
+	if primary.calls != 2 {
+		t.Fatalf("expected the open breaker to skip the primary, got %d calls", primary.calls)
+	}
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+	"exchange-rate-service/pkg/logger"
+	"exchange-rate-service/pkg/metrics"
+)
+
+// consensus asks every provider in the chain at once and merges the answers
+// into a table of median rates. Providers straying from the median by more
+// than MaxDeviationBps are logged so a corrupted feed gets noticed, and left
+// out of the published rates. Two answers cannot outvote each other, so with
+// fewer than three the highest-priority answer is served as in failover.
+func (c *CompositeRepository) consensus(currency string, fetch func(domain_exchange.ExchangeRateExternalRepository) (*domain_exchange.ExchangeRate, error)) (*domain_exchange.ExchangeRate, error) {
+	chain := c.chainFor(currency)
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no provider configured for %s", currency)
+	}
+
+	type answer struct {
+		rate *domain_exchange.ExchangeRate
+		err  error
+	}
+	answers := make([]answer, len(chain))
+
+	var wg sync.WaitGroup
+	for i, repo := range chain {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rate, err := fetch(repo)
+			answers[i] = answer{rate: rate, err: err}
+		}()
+	}
+	wg.Wait()
+
+	var (
+		tables []*domain_exchange.ExchangeRate
+		errs   []error
+	)
+	for i, answer := range answers {
+		name := chain[i].Name()
+		if answer.err != nil {
+			metrics.ProviderResponses.WithLabelValues(name, "failure").Inc()
+			errs = append(errs, fmt.Errorf("%s: %w", name, answer.err))
+			continue
+		}
+		metrics.ProviderResponses.WithLabelValues(name, "success").Inc()
+		tables = append(tables, answer.rate)
+	}
+
+	switch len(tables) {
+	case 0:
+		return nil, errors.Join(errs...)
+	case 1, 2:
+		if len(chain) > 1 {
+			logger.Warnf("Only %d of %d providers answered for %s, serving %s without consensus",
+				len(tables), len(chain), currency, tables[0].Provider)
+		}
+		for _, table := range tables[1:] {
+			c.checkDeviation(currency, table, tables[0].ConversionRates)
+		}
+		return tables[0], nil
+	}
+
+	merged := medianRates(tables)
+	agreeing := make([]*domain_exchange.ExchangeRate, 0, len(tables))
+	for _, table := range tables {
+		if !c.checkDeviation(currency, table, merged) {
+			agreeing = append(agreeing, table)
+		}
+	}
+	if len(agreeing) > 0 && len(agreeing) < len(tables) {
+		tables = agreeing
+		merged = medianRates(tables)
+	}
+
+	providers := make([]string, 0, len(tables))
+	oldest := tables[0]
+	for _, table := range tables {
+		providers = append(providers, table.Provider)
+		if table.FetchedAt.Before(oldest.FetchedAt) {
+			oldest = table
+		}
+	}
+
+	return &domain_exchange.ExchangeRate{
+		Result:          "success",
+		BaseCode:        currency,
+		ConversionRates: merged,
+		FetchedAt:       oldest.FetchedAt,
+		Date:            oldest.Date,
+		Provider:        "consensus(" + strings.Join(providers, ",") + ")",
+	}, nil
+}
+
+// checkDeviation publishes how far a provider's table strays from the median
+// and warns and reports true when it exceeds the configured threshold.
+func (c *CompositeRepository) checkDeviation(currency string, table *domain_exchange.ExchangeRate, median map[string]float64) bool {
+	var (
+		worstCode string
+		worstBps  float64
+	)
+	for code, rate := range table.ConversionRates {
+		reference := median[code]
+		if reference == 0 {
+			continue
+		}
+		if bps := math.Abs(rate-reference) / reference * 10000; bps > worstBps {
+			worstCode, worstBps = code, bps
+		}
+	}
+
+	metrics.ProviderRateDeviation.WithLabelValues(table.Provider, currency).Set(worstBps)
+	if c.options.MaxDeviationBps <= 0 || worstBps <= c.options.MaxDeviationBps {
+		return false
+	}
+	metrics.ProviderOutliers.WithLabelValues(table.Provider).Inc()
+	logger.Warnf("Provider %s deviates %.1f bps from the median for %s/%s (%v vs %v)",
+		table.Provider, worstBps, currency, worstCode, table.ConversionRates[worstCode], median[worstCode])
+	return true
+}
+
+// medianRates returns, per target currency, the median of the rates quoted
+// by the providers that have it.
+func medianRates(tables []*domain_exchange.ExchangeRate) map[string]float64 {
+	quotes := make(map[string][]float64)
+	for _, table := range tables {
+		for code, rate := range table.ConversionRates {
+			quotes[code] = append(quotes[code], rate)
+		}
+	}
+
+	merged := make(map[string]float64, len(quotes))
+	for code, values := range quotes {
+		sort.Float64s(values)
+		mid := len(values) / 2
+		if len(values)%2 == 0 {
+			merged[code] = (values[mid-1] + values[mid]) / 2
+			continue
+		}
+		merged[code] = values[mid]
+	}
+	return merged
+}
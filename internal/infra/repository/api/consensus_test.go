@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+type quotingRepo struct {
+	flakyRepo
+	rates map[string]float64
+}
+
+func (r *quotingRepo) GetLatestRate(ctx context.Context, fromCurrency string) (*domain_exchange.ExchangeRate, error) {
+	rate, err := r.flakyRepo.GetLatestRate(ctx, fromCurrency)
+	if err != nil {
+		return nil, err
+	}
+	rate.ConversionRates = r.rates
+	return rate, nil
+}
+
+func TestCompositeRepository_ConsensusUsesMedian(t *testing.T) {
+	repo := NewCompositeRepository(testRegistry, ProviderChains{
+		domain_exchange.CurrencyTypeFiat: {
+			&quotingRepo{flakyRepo: flakyRepo{name: "a"}, rates: map[string]float64{"EUR": 0.90, "GBP": 0.78}},
+			&quotingRepo{flakyRepo: flakyRepo{name: "b"}, rates: map[string]float64{"EUR": 0.91, "GBP": 0.79}},
+			&quotingRepo{flakyRepo: flakyRepo{name: "c"}, rates: map[string]float64{"EUR": 9.1}},
+		},
+	}, CompositeOptions{Consensus: true, MaxDeviationBps: 200})
+
+	rate, err := repo.GetLatestRate(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// c is an outlier, so only a and b make up the published rates.
+	if got := rate.ConversionRates["EUR"]; math.Abs(got-0.905) > 1e-12 {
+		t.Fatalf("expected the median EUR rate without the outlier, got %v", got)
+	}
+	if got := rate.ConversionRates["GBP"]; got != 0.785 {
+		t.Fatalf("expected mean of the two GBP quotes, got %v", got)
+	}
+	if rate.Provider != "consensus(a,b)" {
+		t.Fatalf("expected consensus of the agreeing providers, got %q", rate.Provider)
+	}
+}
+
+func TestCompositeRepository_ConsensusNeedsThreeAnswers(t *testing.T) {
+	repo := NewCompositeRepository(testRegistry, ProviderChains{
+		domain_exchange.CurrencyTypeFiat: {
+			&quotingRepo{flakyRepo: flakyRepo{name: "a"}, rates: map[string]float64{"EUR": 0.90}},
+			&quotingRepo{flakyRepo: flakyRepo{name: "b"}, rates: map[string]float64{"EUR": 9.0}},
+		},
+	}, CompositeOptions{Consensus: true, MaxDeviationBps: 50})
+
+	rate, err := repo.GetLatestRate(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rate.Provider != "a" || rate.ConversionRates["EUR"] != 0.90 {
+		t.Fatalf("expected the primary's table untouched by the other answer, got %+v", rate)
+	}
+}
+
+func TestCompositeRepository_ConsensusToleratesFailures(t *testing.T) {
+	repo := NewCompositeRepository(testRegistry, ProviderChains{
+		domain_exchange.CurrencyTypeFiat: {
+			&flakyRepo{name: "down", fail: true},
+			&flakyRepo{name: "up"},
+		},
+	}, CompositeOptions{Consensus: true})
+
+	rate, err := repo.GetRateByDate(context.Background(), "USD", "EUR", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rate.Provider != "up" {
+		t.Fatalf("expected the only answering provider, got %q", rate.Provider)
+	}
+}
+
+func TestMedianRates(t *testing.T) {
+	merged := medianRates([]*domain_exchange.ExchangeRate{
+		{ConversionRates: map[string]float64{"EUR": 3, "JPY": 150}},
+		{ConversionRates: map[string]float64{"EUR": 1}},
+		{ConversionRates: map[string]float64{"EUR": 2, "JPY": 152}},
+	})
+
+	if merged["EUR"] != 2 {
+		t.Fatalf("expected median of 2, got %v", merged["EUR"])
+	}
+	if merged["JPY"] != 151 {
+		t.Fatalf("expected median of 151, got %v", merged["JPY"])
+	}
+}
@@ -2,50 +2,159 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	domain_exchange "exchange-rate-service/internal/domain/exchange"
+	"exchange-rate-service/pkg/logger"
+	"exchange-rate-service/pkg/metrics"
 )
 
+// ProviderChains lists, per currency type, the providers to try in order.
+// The first entry is the primary; the rest are only used when it fails.
+type ProviderChains map[string][]domain_exchange.ExchangeRateExternalRepository
+
+// CompositeOptions tunes how the composite repository uses its chains.
+type CompositeOptions struct {
+	// MaxStaleness is the age after which a latest table is treated like a
+	// failure and the next provider is asked; zero disables the check.
+	MaxStaleness time.Duration
+	// Consensus asks every provider of the chain concurrently and answers
+	// with the median rate instead of the first provider that succeeds.
+	Consensus bool
+	// MaxDeviationBps is how far, in basis points, a provider may stray from
+	// the median before it is flagged in consensus mode.
+	MaxDeviationBps float64
+}
+
 type CompositeRepository struct {
-	exchangeRateRepos []domain_exchange.ExchangeRateExternalRepository
+	registry domain_exchange.CurrencyRegistry
+	chains   ProviderChains
+	options  CompositeOptions
 }
 
+// NewCompositeRepository routes each base currency to the provider chain of
+// its type. Currencies whose type has no chain use the fiat chain.
 func NewCompositeRepository(
-	exchangeRateRepos ...domain_exchange.ExchangeRateExternalRepository,
+	registry domain_exchange.CurrencyRegistry,
+	chains ProviderChains,
+	options CompositeOptions,
 ) domain_exchange.ExchangeRateExternalRepository {
 	return &CompositeRepository{
-		exchangeRateRepos: exchangeRateRepos,
+		registry: registry,
+		chains:   chains,
+		options:  options,
 	}
 }
 
+func (c *CompositeRepository) Name() string {
+	return "composite"
+}
+
+// ProviderFor returns the primary provider for the currency.
+func (c *CompositeRepository) ProviderFor(currency string) string {
+	chain := c.chainFor(currency)
+	if len(chain) == 0 {
+		return ""
+	}
+	return chain[0].Name()
+}
+
 func (c *CompositeRepository) GetLatestRate(ctx context.Context, fromCurrency string) (*domain_exchange.ExchangeRate, error) {
-	repo := c.SelectRepo(fromCurrency)
-	return repo.GetLatestRate(ctx, fromCurrency)
+	var (
+		mu    sync.Mutex
+		stale *domain_exchange.ExchangeRate
+	)
+	fetch := func(repo domain_exchange.ExchangeRateExternalRepository) (*domain_exchange.ExchangeRate, error) {
+		rate, err := repo.GetLatestRate(ctx, fromCurrency)
+		if err != nil {
+			return nil, err
+		}
+		// Tables a provider serves from memory during an outage are marked
+		// stale; like outdated tables they only win when nothing fresher does.
+		if rate.Stale || (c.options.MaxStaleness > 0 && time.Since(rate.FetchedAt) > c.options.MaxStaleness) {
+			mu.Lock()
+			if stale == nil || rate.FetchedAt.After(stale.FetchedAt) {
+				stale = rate
+			}
+			mu.Unlock()
+			return nil, fmt.Errorf("table fetched at %s is stale", rate.FetchedAt.Format(time.RFC3339))
+		}
+		return rate, nil
+	}
+
+	var (
+		rate *domain_exchange.ExchangeRate
+		err  error
+	)
+	if c.options.Consensus {
+		rate, err = c.consensus(fromCurrency, fetch)
+	} else {
+		rate, err = failover(ctx, c, fromCurrency, fetch)
+	}
+	if err != nil && stale != nil {
+		// A stale answer still beats no answer when every provider is behind.
+		logger.Warnf("No provider has a fresh table for %s, serving stale table from %s", fromCurrency, stale.Provider)
+		return stale, nil
+	}
+	return rate, err
 }
 
 func (c *CompositeRepository) GetRateByDate(ctx context.Context, fromCurrency, toCurrency string, date time.Time) (*domain_exchange.ExchangeRate, error) {
-	repo := c.SelectRepo(fromCurrency)
-	return repo.GetRateByDate(ctx, fromCurrency, toCurrency, date)
+	fetch := func(repo domain_exchange.ExchangeRateExternalRepository) (*domain_exchange.ExchangeRate, error) {
+		return repo.GetRateByDate(ctx, fromCurrency, toCurrency, date)
+	}
+	if c.options.Consensus {
+		return c.consensus(fromCurrency, fetch)
+	}
+	return failover(ctx, c, fromCurrency, fetch)
 }
 
 func (c *CompositeRepository) GetRatesForDateRange(ctx context.Context, fromCurrency, toCurrency string, startDate, endDate time.Time) ([]*domain_exchange.ExchangeRate, error) {
-	repo := c.SelectRepo(fromCurrency)
-	return repo.GetRatesForDateRange(ctx, fromCurrency, toCurrency, startDate, endDate)
+	return failover(ctx, c, fromCurrency, func(repo domain_exchange.ExchangeRateExternalRepository) ([]*domain_exchange.ExchangeRate, error) {
+		return repo.GetRatesForDateRange(ctx, fromCurrency, toCurrency, startDate, endDate)
+	})
 }
 
-func (c *CompositeRepository) SelectRepo(fromCurrency string) domain_exchange.ExchangeRateExternalRepository {
-	// 0 -> Fiat
-	// 1 -> Crypto
-	// 2 -> Mock
+func (c *CompositeRepository) chainFor(currency string) []domain_exchange.ExchangeRateExternalRepository {
+	info, _ := c.registry.Get(currency)
+	if chain, exists := c.chains[info.Type]; exists && len(chain) > 0 {
+		return chain
+	}
+	return c.chains[domain_exchange.CurrencyTypeFiat]
+}
 
-	if isCryptoSymbol(fromCurrency) {
-		return c.exchangeRateRepos[1]
+// failover asks each provider in the currency's chain until one answers and
+// returns every provider's error when none does.
+func failover[T any](ctx context.Context, c *CompositeRepository, currency string, fetch func(domain_exchange.ExchangeRateExternalRepository) (T, error)) (T, error) {
+	var zero T
+	chain := c.chainFor(currency)
+	if len(chain) == 0 {
+		return zero, fmt.Errorf("no provider configured for %s", currency)
 	}
 
-	return c.exchangeRateRepos[0]
-}
+	var errs []error
+	for i, repo := range chain {
+		result, err := fetch(repo)
+		if err == nil {
+			metrics.ProviderResponses.WithLabelValues(repo.Name(), "success").Inc()
+			if i > 0 {
+				logger.Warnf("Served %s from fallback provider %s", currency, repo.Name())
+			}
+			return result, nil
+		}
 
-func isCryptoSymbol(symbol string) bool {
-	return domain_exchange.SupportedCurrencies[symbol].Type == "crypto"
+		metrics.ProviderResponses.WithLabelValues(repo.Name(), "failure").Inc()
+		errs = append(errs, fmt.Errorf("%s: %w", repo.Name(), err))
+		if ctx.Err() != nil {
+			// The caller gave up, so there is no point in asking the rest.
+			break
+		}
+		if i < len(chain)-1 {
+			logger.Warnf("Provider %s failed for %s, trying %s: %v", repo.Name(), currency, chain[i+1].Name(), err)
+		}
+	}
+	return zero, errors.Join(errs...)
 }
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+	"exchange-rate-service/internal/infra/registry"
+)
+
+var testRegistry = registry.NewStaticRegistry(domain_exchange.DefaultCurrencies)
+
+func TestCompositeRepository_FallsThroughOnError(t *testing.T) {
+	primary := &flakyRepo{name: "primary", fail: true}
+	secondary := &flakyRepo{name: "secondary"}
+	repo := NewCompositeRepository(testRegistry, ProviderChains{
+		domain_exchange.CurrencyTypeFiat: {primary, secondary},
+	}, CompositeOptions{MaxStaleness: time.Hour})
+
+	rate, err := repo.GetRateByDate(context.Background(), "USD", "EUR", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rate.Provider != "secondary" {
+		t.Fatalf("expected secondary provider to answer, got %q", rate.Provider)
+	}
+	if primary.calls != 1 || secondary.calls != 1 {
+		t.Fatalf("expected each provider to be asked once, got %d and %d", primary.calls, secondary.calls)
+	}
+}
+
+func TestCompositeRepository_FallsThroughOnStaleTable(t *testing.T) {
+	primary := &flakyRepo{name: "primary", fetchedAt: time.Now().Add(-3 * time.Hour)}
+	secondary := &flakyRepo{name: "secondary"}
+	repo := NewCompositeRepository(testRegistry, ProviderChains{
+		domain_exchange.CurrencyTypeFiat: {primary, secondary},
+	}, CompositeOptions{MaxStaleness: time.Hour})
+
+	rate, err := repo.GetLatestRate(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rate.Provider != "secondary" {
+		t.Fatalf("expected fresh table from secondary, got %q", rate.Provider)
+	}
+}
+
+func TestCompositeRepository_ServesStaleWhenNothingFresher(t *testing.T) {
+	primary := &flakyRepo{name: "primary", fetchedAt: time.Now().Add(-3 * time.Hour)}
+	secondary := &flakyRepo{name: "secondary", fail: true}
+	repo := NewCompositeRepository(testRegistry, ProviderChains{
+		domain_exchange.CurrencyTypeFiat: {primary, secondary},
+	}, CompositeOptions{MaxStaleness: time.Hour})
+
+	rate, err := repo.GetLatestRate(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("expected stale table instead of error: %v", err)
+	}
+	if rate.Provider != "primary" {
+		t.Fatalf("expected stale table from primary, got %q", rate.Provider)
+	}
+}
+
+func TestCompositeRepository_RoutesByCurrencyType(t *testing.T) {
+	fiat := &flakyRepo{name: "fiat"}
+	crypto := &flakyRepo{name: "crypto"}
+	repo := NewCompositeRepository(testRegistry, ProviderChains{
+		domain_exchange.CurrencyTypeFiat:   {fiat},
+		domain_exchange.CurrencyTypeCrypto: {crypto},
+	}, CompositeOptions{}).(*CompositeRepository)
+
+	if got := repo.ProviderFor("BTC"); got != "crypto" {
+		t.Fatalf("expected crypto provider for BTC, got %q", got)
+	}
+	if got := repo.ProviderFor("EUR"); got != "fiat" {
+		t.Fatalf("expected fiat provider for EUR, got %q", got)
+	}
+
+	rates, err := repo.GetRatesForDateRange(context.Background(), "BTC", "USD", time.Now(), time.Now())
+	if err != nil || len(rates) != 1 || rates[0].Provider != "crypto" {
+		t.Fatalf("expected range from crypto provider, got %v, %v", rates, err)
+	}
+}
+
+func TestCompositeRepository_ReportsAllFailures(t *testing.T) {
+	repo := NewCompositeRepository(testRegistry, ProviderChains{
+		domain_exchange.CurrencyTypeFiat: {&flakyRepo{name: "primary", fail: true}, &flakyRepo{name: "secondary", fail: true}},
+	}, CompositeOptions{MaxStaleness: time.Hour})
+
+	_, err := repo.GetLatestRate(context.Background(), "USD")
+	if err == nil {
+		t.Fatal("expected error when every provider fails")
+	}
+	for _, name := range []string{"primary", "secondary"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Fatalf("expected error to mention %s, got %v", name, err)
+		}
+	}
+}
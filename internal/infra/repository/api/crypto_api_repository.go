@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	domain_exchange "exchange-rate-service/internal/domain/exchange"
@@ -13,11 +15,28 @@ import (
 	"exchange-rate-service/pkg/logger"
 )
 
+const cryptoProviderName = "coinlayer"
+
+// coinlayerTarget is the currency coinlayer is asked to quote prices in.
+const coinlayerTarget = "USD"
+
+// Coinlayer error codes for exhausted monthly and per-second quotas.
+const (
+	coinlayerUsageLimitReached = 104
+	coinlayerRateLimitReached  = 106
+)
+
 type coinlayerLiveResp struct {
 	Success   bool               `json:"success"`
 	Timestamp int64              `json:"timestamp"`
 	Target    string             `json:"target"`
 	Rates     map[string]float64 `json:"rates"`
+	Error     *coinlayerError    `json:"error"`
+}
+
+type coinlayerError struct {
+	Code int    `json:"code"`
+	Type string `json:"type"`
 }
 
 type cryptoAPIRepository struct {
@@ -34,77 +53,69 @@ func NewCryptoAPIRepository(httpClient http_client.HTTPClient, baseURL, apiKey s
 	}
 }
 
-func (r *cryptoAPIRepository) GetLatestRate(ctx context.Context, fromCurrency string) (*domain_exchange.ExchangeRate, error) {
-	q := url.Values{}
-	q.Set("access_key", r.apiKey)
+func (r *cryptoAPIRepository) Name() string {
+	return cryptoProviderName
+}
 
-	endpoint := fmt.Sprintf("%s/live?%s", r.baseURL, q.Encode())
-	resp, err := r.httpClient.Get(ctx, endpoint, nil)
+func (r *cryptoAPIRepository) GetLatestRate(ctx context.Context, fromCurrency string) (*domain_exchange.ExchangeRate, error) {
+	res, err := r.fetch(ctx, "live")
 	if err != nil {
 		return nil, fmt.Errorf("coinlayer latest request failed: %w", err)
 	}
-
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("coinlayer status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var res coinlayerLiveResp
-	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
-		return nil, fmt.Errorf("coinlayer unmarshal: %w", err)
-	}
-	if !res.Success {
-		return nil, fmt.Errorf("coinlayer returned success=false")
-	}
-
-	// Coinlayer returns a price table with target USD: Rates[symbol] = price in USD for 1 unit of symbol.
-	// Normalize into conversion table (target-per-from) using USD as pivot:
-	// rate(from -> x) = USDPrice(from) / USDPrice(x). For USD, USDPrice(USD) = 1, so rate(USD->x) = 1/USDPrice(x).
-	usdPrices := res.Rates
-	// Ensure USD is present and equals 1 if missing
-	if _, ok := usdPrices["USD"]; !ok {
-		usdPrices["USD"] = 1
-	}
-
-	fromUSDPrice, ok := usdPrices[fromCurrency]
-	if !ok || fromUSDPrice == 0 {
-		return nil, fmt.Errorf("coinlayer price in USD for %s not available", fromCurrency)
-	}
-
-	conversion := make(map[string]float64, len(usdPrices))
-	for symbol, usdPrice := range usdPrices {
-		if usdPrice == 0 {
-			continue
-		}
-		conversion[symbol] = fromUSDPrice / usdPrice
+	conversion, err := normalizeCoinlayerRates(res, fromCurrency)
+	if err != nil {
+		return nil, err
 	}
-	// Identity
-	conversion[fromCurrency] = 1
 
+	now := time.Now()
 	rate := &domain_exchange.ExchangeRate{
 		Result:          "success",
 		BaseCode:        fromCurrency,
 		ConversionRates: conversion,
-		FetchedAt:       time.Now(),
+		FetchedAt:       now,
+		Date:            domain_exchange.StartOfDay(now),
+		Provider:        cryptoProviderName,
 	}
 	logger.Infof("Fetched latest table via coinlayer; base=%s, target=%s (USD pivot)", fromCurrency, res.Target)
 	return rate, nil
 }
 
 func (r *cryptoAPIRepository) GetRateByDate(ctx context.Context, fromCurrency, toCurrency string, date time.Time) (*domain_exchange.ExchangeRate, error) {
-	dateStr := date.Format("2006-01-02")
+	res, err := r.fetch(ctx, date.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch historical rate: %w", err)
+	}
+	conversion, err := normalizeCoinlayerRates(res, fromCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain_exchange.ExchangeRate{
+		Result:          "success",
+		BaseCode:        fromCurrency,
+		ConversionRates: conversion,
+		FetchedAt:       time.Now(),
+		Date:            domain_exchange.StartOfDay(date),
+		Provider:        cryptoProviderName,
+	}, nil
+}
 
-	url := fmt.Sprintf("%s/%s?access_key=%s", r.baseURL, dateStr, r.apiKey)
+// fetch calls a coinlayer endpoint ("live" or a YYYY-MM-DD day) for prices
+// in coinlayerTarget.
+func (r *cryptoAPIRepository) fetch(ctx context.Context, endpoint string) (*coinlayerLiveResp, error) {
+	q := url.Values{}
+	q.Set("access_key", r.apiKey)
+	q.Set("target", coinlayerTarget)
 
-	resp, err := r.httpClient.Get(ctx, url, nil)
+	resp, err := r.httpClient.Get(ctx, fmt.Sprintf("%s/%s?%s", r.baseURL, endpoint, q.Encode()), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch historical rate: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		return nil, statusError(resp.StatusCode, "coinlayer status %d: %s", resp.StatusCode, string(body))
 	}
 
 	var res coinlayerLiveResp
@@ -112,28 +123,57 @@ func (r *cryptoAPIRepository) GetRateByDate(ctx context.Context, fromCurrency, t
 		return nil, fmt.Errorf("coinlayer unmarshal: %w", err)
 	}
 	if !res.Success {
+		if res.Error != nil {
+			// Coinlayer reports exhausted quotas in the body of a 200 response.
+			status := resp.StatusCode
+			if res.Error.Code == coinlayerUsageLimitReached || res.Error.Code == coinlayerRateLimitReached {
+				status = http.StatusTooManyRequests
+			}
+			return nil, statusError(status, "coinlayer returned error %d: %s", res.Error.Code, res.Error.Type)
+		}
 		return nil, fmt.Errorf("coinlayer returned success=false")
 	}
-
-	return &domain_exchange.ExchangeRate{
-		Result:          "success",
-		BaseCode:        fromCurrency,
-		ConversionRates: res.Rates,
-		FetchedAt:       time.Now(),
-	}, nil
+	return &res, nil
 }
 
-func (r *cryptoAPIRepository) GetRatesForDateRange(ctx context.Context, fromCurrency, toCurrency string, startDate, endDate time.Time) ([]*domain_exchange.ExchangeRate, error) {
-	var rates []*domain_exchange.ExchangeRate
+// normalizeCoinlayerRates turns coinlayer's price table into conversion rates
+// from fromCurrency. Coinlayer quotes Rates[symbol] as the price of one unit
+// of symbol in the target currency, so with the target as pivot:
+// rate(from -> x) = price(from) / price(x), where price(target) = 1.
+func normalizeCoinlayerRates(res *coinlayerLiveResp, fromCurrency string) (map[string]float64, error) {
+	target := strings.ToUpper(res.Target)
+	if target == "" {
+		target = coinlayerTarget
+	}
+	if target != coinlayerTarget {
+		return nil, fmt.Errorf("coinlayer quoted prices in %s, expected %s", target, coinlayerTarget)
+	}
 
-	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
-		rate, err := r.GetRateByDate(ctx, fromCurrency, toCurrency, d)
-		if err != nil {
-			logger.Errorf("Failed to fetch rate for %s: %v", d.Format("2006-01-02"), err)
+	prices := make(map[string]float64, len(res.Rates)+1)
+	for symbol, price := range res.Rates {
+		prices[symbol] = price
+	}
+	prices[target] = 1
+
+	fromPrice, ok := prices[fromCurrency]
+	if !ok || fromPrice == 0 {
+		return nil, fmt.Errorf("coinlayer price in %s for %s not available", target, fromCurrency)
+	}
+
+	conversion := make(map[string]float64, len(prices))
+	for symbol, price := range prices {
+		if price == 0 {
 			continue
 		}
-		rates = append(rates, rate)
+		conversion[symbol] = fromPrice / price
 	}
+	// Identity
+	conversion[fromCurrency] = 1
+	return conversion, nil
+}
 
-	return rates, nil
+func (r *cryptoAPIRepository) GetRatesForDateRange(ctx context.Context, fromCurrency, toCurrency string, startDate, endDate time.Time) ([]*domain_exchange.ExchangeRate, error) {
+	return ratesByDay(ctx, startDate, endDate, func(date time.Time) (*domain_exchange.ExchangeRate, error) {
+		return r.GetRateByDate(ctx, fromCurrency, toCurrency, date)
+	})
 }
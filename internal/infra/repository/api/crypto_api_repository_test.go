@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+	"exchange-rate-service/internal/infra/http_client"
+)
+
+// newCoinlayerStub serves body for every request and records the last URL.
+func newCoinlayerStub(t *testing.T, body string, lastURL *url.URL) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*lastURL = *r.URL
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestCryptoRepo(baseURL string) *cryptoAPIRepository {
+	return NewCryptoAPIRepository(http_client.NewHTTPClient(time.Second), baseURL, "key").(*cryptoAPIRepository)
+}
+
+const coinlayerPrices = `{"success":true,"timestamp":1704153600,"target":"USD","rates":{"BTC":40000,"ETH":2000}}`
+
+func TestCryptoAPIRepository_NormalizesLatestAndHistoricalAlike(t *testing.T) {
+	var request url.URL
+	server := newCoinlayerStub(t, coinlayerPrices, &request)
+	repo := newTestCryptoRepo(server.URL)
+	ctx := context.Background()
+	date := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	latest, err := repo.GetLatestRate(ctx, "BTC")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if request.Path != "/live" || request.Query().Get("target") != "USD" {
+		t.Fatalf("unexpected latest request %s", &request)
+	}
+
+	historical, err := repo.GetRateByDate(ctx, "BTC", "USD", date)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if request.Path != "/2024-01-02" {
+		t.Fatalf("unexpected historical request %s", &request)
+	}
+	if !historical.Date.Equal(date) {
+		t.Fatalf("expected table for %v, got %v", date, historical.Date)
+	}
+
+	for _, table := range []map[string]float64{latest.ConversionRates, historical.ConversionRates} {
+		if table["USD"] != 40000 || table["ETH"] != 20 || table["BTC"] != 1 {
+			t.Fatalf("expected conversion rates from BTC, got %v", table)
+		}
+	}
+}
+
+func TestCryptoAPIRepository_NormalizesFromTarget(t *testing.T) {
+	var request url.URL
+	server := newCoinlayerStub(t, coinlayerPrices, &request)
+	repo := newTestCryptoRepo(server.URL)
+
+	rate, err := repo.GetRateByDate(context.Background(), "USD", "BTC", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(rate.ConversionRates["BTC"]-1.0/40000) > 1e-15 || rate.ConversionRates["USD"] != 1 {
+		t.Fatalf("expected USD-based rates, got %v", rate.ConversionRates)
+	}
+}
+
+func TestCryptoAPIRepository_RejectsUnexpectedTarget(t *testing.T) {
+	var request url.URL
+	server := newCoinlayerStub(t, `{"success":true,"target":"EUR","rates":{"BTC":37000}}`, &request)
+	repo := newTestCryptoRepo(server.URL)
+	ctx := context.Background()
+
+	if _, err := repo.GetLatestRate(ctx, "BTC"); err == nil {
+		t.Fatal("expected latest prices in EUR to be rejected")
+	}
+	if _, err := repo.GetRateByDate(ctx, "BTC", "USD", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Fatal("expected historical prices in EUR to be rejected")
+	}
+}
+
+func TestCryptoAPIRepository_UnknownBaseAndFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		from string
+	}{
+		{name: "unknown base", body: coinlayerPrices, from: "DOGE"},
+		{name: "success false", body: `{"success":false,"error":{"code":101}}`, from: "BTC"},
+		{name: "malformed body", body: `{"success":`, from: "BTC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request url.URL
+			server := newCoinlayerStub(t, tt.body, &request)
+			repo := newTestCryptoRepo(server.URL)
+			if _, err := repo.GetRateByDate(context.Background(), tt.from, "USD", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestCryptoAPIRepository_ClassifiesRateLimits(t *testing.T) {
+	var request url.URL
+	server := newCoinlayerStub(t, `{"success":false,"error":{"code":106,"type":"rate_limit_reached"}}`, &request)
+	repo := newTestCryptoRepo(server.URL)
+
+	_, err := repo.GetLatestRate(context.Background(), "BTC")
+	if !errors.Is(err, domain_exchange.ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+	"exchange-rate-service/pkg/logger"
+)
+
+// ratesByDay fetches a range one day at a time, since no provider has a range
+// endpoint. Days the provider has no table for are skipped; an outage fails
+// the whole range, as the remaining days would only fail the same way.
+func ratesByDay(ctx context.Context, startDate, endDate time.Time, get func(date time.Time) (*domain_exchange.ExchangeRate, error)) ([]*domain_exchange.ExchangeRate, error) {
+	var rates []*domain_exchange.ExchangeRate
+	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+		rate, err := get(d)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, domain_exchange.ErrCircuitOpen) || isProviderOutage(err) {
+				return nil, fmt.Errorf("failed to fetch rate for %s: %w", d.Format("2006-01-02"), err)
+			}
+			logger.Errorf("Failed to fetch rate for %s: %v", d.Format("2006-01-02"), err)
+			continue
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
@@ -12,6 +12,8 @@ import (
 	"exchange-rate-service/pkg/logger"
 )
 
+const fiatProviderName = "exchangerate-api"
+
 type externalAPIRepository struct {
 	httpClient http_client.HTTPClient
 	baseURL    string
@@ -26,6 +28,10 @@ func NewExternalAPIRepository(httpClient http_client.HTTPClient, baseURL, apiKey
 	}
 }
 
+func (r *externalAPIRepository) Name() string {
+	return fiatProviderName
+}
+
 func (r *externalAPIRepository) GetLatestRate(ctx context.Context, fromCurrency string) (*domain_exchange.ExchangeRate, error) {
 	url := fmt.Sprintf("%s/%s/latest/%s", r.baseURL, r.apiKey, fromCurrency)
 
@@ -36,7 +42,7 @@ func (r *externalAPIRepository) GetLatestRate(ctx context.Context, fromCurrency
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, statusError(resp.StatusCode, "API returned status %d", resp.StatusCode)
 	}
 	var rate domain_exchange.ExchangeRate
 	if err := json.NewDecoder(resp.Body).Decode(&rate); err != nil {
@@ -45,6 +51,8 @@ func (r *externalAPIRepository) GetLatestRate(ctx context.Context, fromCurrency
 
 	now := time.Now()
 	rate.FetchedAt = now
+	rate.Date = domain_exchange.StartOfDay(now)
+	rate.Provider = fiatProviderName
 	logger.Infof("Fetched latest rate for %s from external API", fromCurrency)
 
 	return &rate, nil
@@ -63,7 +71,7 @@ func (r *externalAPIRepository) GetRateByDate(ctx context.Context, fromCurrency,
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, statusError(resp.StatusCode, "API returned status %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -77,22 +85,15 @@ func (r *externalAPIRepository) GetRateByDate(ctx context.Context, fromCurrency,
 	}
 
 	rate.FetchedAt = time.Now()
+	rate.Date = domain_exchange.StartOfDay(date)
+	rate.Provider = fiatProviderName
 	logger.Infof("Fetched historical rate for %s on %s from external API", fromCurrency, dateStr)
 
 	return &rate, nil
 }
 
 func (r *externalAPIRepository) GetRatesForDateRange(ctx context.Context, fromCurrency, toCurrency string, startDate, endDate time.Time) ([]*domain_exchange.ExchangeRate, error) {
-	var rates []*domain_exchange.ExchangeRate
-
-	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
-		rate, err := r.GetRateByDate(ctx, fromCurrency, toCurrency, d)
-		if err != nil {
-			logger.Errorf("Failed to fetch rate for %s: %v", d.Format("2006-01-02"), err)
-			continue
-		}
-		rates = append(rates, rate)
-	}
-
-	return rates, nil
+	return ratesByDay(ctx, startDate, endDate, func(date time.Time) (*domain_exchange.ExchangeRate, error) {
+		return r.GetRateByDate(ctx, fromCurrency, toCurrency, date)
+	})
 }
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+// providerStatusError keeps the HTTP status of a failed provider response so
+// that callers can tell outages from requests the provider rejected.
+type providerStatusError struct {
+	status int
+	err    error
+}
+
+func (e *providerStatusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *providerStatusError) Unwrap() error {
+	return e.err
+}
+
+// statusError reports a non-200 provider response, classified as rate
+// limiting for 429 and as an upstream failure otherwise.
+func statusError(status int, format string, args ...any) error {
+	kind := domain_exchange.ErrUpstreamFailure
+	if status == http.StatusTooManyRequests {
+		kind = domain_exchange.ErrRateLimited
+	}
+	return &providerStatusError{status: status, err: domain_exchange.Errorf(kind, format, args...)}
+}
+
+// isProviderOutage reports whether err says the provider itself is unwell:
+// a transport failure, a 5xx or a 429. Rejected requests and unexpected
+// bodies are about the request and do not count.
+func isProviderOutage(err error) bool {
+	var statusErr *providerStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status == http.StatusTooManyRequests || statusErr.status >= http.StatusInternalServerError
+	}
+	var transportErr *url.Error
+	return errors.As(err, &transportErr)
+}
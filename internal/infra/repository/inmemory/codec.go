@@ -0,0 +1,58 @@
+package inmemory
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	exchange "exchange-rate-service/internal/domain/exchange"
+	"exchange-rate-service/pkg/cache"
+)
+
+// storedExchangeRate mirrors exchange.ExchangeRate with every field
+// serialized; the entity's own JSON tags follow the upstream API and drop
+// FetchedAt, Date and Provider.
+type storedExchangeRate struct {
+	Result          string             `json:"result"`
+	BaseCode        string             `json:"base_code"`
+	ConversionRates map[string]float64 `json:"conversion_rates"`
+	FetchedAt       time.Time          `json:"fetched_at"`
+	Date            time.Time          `json:"date"`
+	Provider        string             `json:"provider"`
+}
+
+type exchangeRateCodec struct{}
+
+func NewExchangeRateCodec() cache.Codec {
+	return exchangeRateCodec{}
+}
+
+func (exchangeRateCodec) Marshal(value any) ([]byte, error) {
+	rate, ok := value.(*exchange.ExchangeRate)
+	if !ok {
+		return nil, fmt.Errorf("unexpected cache value type %T", value)
+	}
+	return json.Marshal(storedExchangeRate{
+		Result:          rate.Result,
+		BaseCode:        rate.BaseCode,
+		ConversionRates: rate.ConversionRates,
+		FetchedAt:       rate.FetchedAt,
+		Date:            rate.Date,
+		Provider:        rate.Provider,
+	})
+}
+
+func (exchangeRateCodec) Unmarshal(data []byte) (any, error) {
+	var stored storedExchangeRate
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode cached rate: %w", err)
+	}
+	return &exchange.ExchangeRate{
+		Result:          stored.Result,
+		BaseCode:        stored.BaseCode,
+		ConversionRates: stored.ConversionRates,
+		FetchedAt:       stored.FetchedAt,
+		Date:            stored.Date,
+		Provider:        stored.Provider,
+	}, nil
+}
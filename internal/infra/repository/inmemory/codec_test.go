@@ -0,0 +1,40 @@
+package inmemory
+
+import (
+	"testing"
+	"time"
+
+	exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+func TestExchangeRateCodec_RoundTrip(t *testing.T) {
+	codec := NewExchangeRateCodec()
+	rate := &exchange.ExchangeRate{
+		Result:          "success",
+		BaseCode:        "USD",
+		ConversionRates: map[string]float64{"EUR": 0.9},
+		FetchedAt:       time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+		Date:            time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Provider:        "exchangerate-api",
+	}
+
+	data, err := codec.Marshal(rate)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	value, err := codec.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	decoded, ok := value.(*exchange.ExchangeRate)
+	if !ok {
+		t.Fatalf("expected *ExchangeRate, got %T", value)
+	}
+	if !decoded.FetchedAt.Equal(rate.FetchedAt) || !decoded.Date.Equal(rate.Date) || decoded.Provider != rate.Provider {
+		t.Fatalf("metadata lost in round trip: %+v", decoded)
+	}
+	if decoded.ConversionRates["EUR"] != 0.9 {
+		t.Fatalf("rates lost in round trip: %+v", decoded.ConversionRates)
+	}
+}
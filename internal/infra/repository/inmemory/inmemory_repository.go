@@ -11,18 +11,44 @@ import (
 	"exchange-rate-service/pkg/cache"
 )
 
+// TTLPolicy decides how long a table stays cached. Tables for today use
+// Latest, or CryptoLatest when the base is a crypto currency; tables for
+// closed days never change and use Historical, where zero means forever.
+type TTLPolicy struct {
+	Latest       time.Duration
+	CryptoLatest time.Duration
+	Historical   time.Duration
+}
+
+func (p TTLPolicy) ttlFor(rate *exchange.ExchangeRate, currencyType string) time.Duration {
+	if rate.Date.Before(exchange.StartOfDay(time.Now())) {
+		if p.Historical <= 0 {
+			return cache.NoExpiration
+		}
+		return p.Historical
+	}
+	if currencyType == exchange.CurrencyTypeCrypto && p.CryptoLatest > 0 {
+		return p.CryptoLatest
+	}
+	return p.Latest
+}
+
 type inMemoryRepository struct {
-	cache cache.Cache
+	cache    cache.Cache
+	registry exchange.CurrencyRegistry
+	policy   TTLPolicy
 }
 
-func NewInMemoryRepository(cache cache.Cache) exchange.ExchangeRateCacheRepository {
+func NewInMemoryRepository(cache cache.Cache, registry exchange.CurrencyRegistry, policy TTLPolicy) exchange.ExchangeRateCacheRepository {
 	return &inMemoryRepository{
-		cache: cache,
+		cache:    cache,
+		registry: registry,
+		policy:   policy,
 	}
 }
 
 func (r *inMemoryRepository) generateCacheKey(fromCurrency string, date time.Time) string {
-	return fmt.Sprintf("rate:%s:%s", fromCurrency, date.Format("2006-01-02"))
+	return fmt.Sprintf("rate:%s:%s", fromCurrency, exchange.StartOfDay(date).Format("2006-01-02"))
 }
 
 func (r *inMemoryRepository) GetLatestRate(ctx context.Context, fromCurrency string) (*exchange.ExchangeRate, error) {
@@ -67,12 +93,16 @@ func (r *inMemoryRepository) StoreRate(ctx context.Context, rate *exchange.Excha
 	if rate == nil {
 		return fmt.Errorf("rate cannot be nil")
 	}
+	if rate.Date.IsZero() {
+		return fmt.Errorf("rate for %s has no effective date", rate.BaseCode)
+	}
 
-	key := r.generateCacheKey(rate.BaseCode, time.Now())
-	if err := r.cache.Set(key, rate, 1*time.Hour); err != nil {
+	currency, _ := r.registry.Get(rate.BaseCode)
+	key := r.generateCacheKey(rate.BaseCode, rate.Date)
+	if err := r.cache.Set(key, rate, r.policy.ttlFor(rate, currency.Type)); err != nil {
 		return fmt.Errorf("failed to store rate in cache: %w", err)
 	}
-	logger.Infof("Stored rate for %s in cache", rate.BaseCode)
+	logger.Infof("Stored rate for %s on %s in cache", rate.BaseCode, rate.Date.Format("2006-01-02"))
 	return nil
 }
 
@@ -84,7 +114,10 @@ func (r *inMemoryRepository) CacheRate(ctx context.Context, rate *exchange.Excha
 	if rate == nil {
 		return fmt.Errorf("rate cannot be nil")
 	}
-	key := r.generateCacheKey(rate.BaseCode, time.Now())
+	if rate.Date.IsZero() {
+		return fmt.Errorf("rate for %s has no effective date", rate.BaseCode)
+	}
+	key := r.generateCacheKey(rate.BaseCode, rate.Date)
 	if err := r.cache.Set(key, rate, ttl); err != nil {
 		return fmt.Errorf("failed to cache rate: %w", err)
 	}
@@ -0,0 +1,66 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	exchange "exchange-rate-service/internal/domain/exchange"
+	"exchange-rate-service/internal/infra/registry"
+	"exchange-rate-service/pkg/cache"
+)
+
+type recordingCache struct {
+	ttls map[string]time.Duration
+}
+
+func (c *recordingCache) Set(key string, value any, ttl time.Duration) error {
+	c.ttls[key] = ttl
+	return nil
+}
+
+func (c *recordingCache) Get(key string) (any, bool) {
+	return nil, false
+}
+
+func (c *recordingCache) Close() error {
+	return nil
+}
+
+func TestStoreRate_AppliesTTLPolicy(t *testing.T) {
+	today := exchange.StartOfDay(time.Now())
+	yesterday := today.AddDate(0, 0, -1)
+	policy := TTLPolicy{Latest: time.Hour, CryptoLatest: time.Minute}
+
+	tests := []struct {
+		name string
+		base string
+		date time.Time
+		want time.Duration
+	}{
+		{"fiat latest", "USD", today, time.Hour},
+		{"crypto latest", "BTC", today, time.Minute},
+		{"closed fiat day", "USD", yesterday, cache.NoExpiration},
+		{"closed crypto day", "BTC", yesterday, cache.NoExpiration},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &recordingCache{ttls: make(map[string]time.Duration)}
+			repo := NewInMemoryRepository(c, registry.NewStaticRegistry(exchange.DefaultCurrencies), policy)
+
+			err := repo.StoreRate(context.Background(), &exchange.ExchangeRate{
+				BaseCode:        tt.base,
+				ConversionRates: map[string]float64{"USD": 1},
+				Date:            tt.date,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			key := "rate:" + tt.base + ":" + tt.date.Format("2006-01-02")
+			if got := c.ttls[key]; got != tt.want {
+				t.Fatalf("expected ttl %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -8,27 +8,36 @@ import (
 	entity "exchange-rate-service/internal/domain/exchange"
 )
 
+const providerName = "mock"
+
 type MockExchangeRateRepository struct {
-	//Doesn't need anything``
+	registry entity.CurrencyRegistry
+}
+
+func NewMockExchangeRateRepository(registry entity.CurrencyRegistry) entity.ExchangeRateExternalRepository {
+	return &MockExchangeRateRepository{registry: registry}
 }
 
-func NewMockExchangeRateRepository() entity.ExchangeRateExternalRepository {
-	return &MockExchangeRateRepository{}
+func (m *MockExchangeRateRepository) Name() string {
+	return providerName
 }
 
 func (m *MockExchangeRateRepository) GetLatestRate(ctx context.Context, fromCurrency string) (*entity.ExchangeRate, error) {
 	conversionRates := make(map[string]float64)
 	conversionRates[fromCurrency] = 1.0
 
-	for currency := range entity.SupportedCurrencies {
-		conversionRates[currency] = (rand.Float64() * 5)
+	for _, currency := range m.registry.List() {
+		conversionRates[currency.Code] = (rand.Float64() * 5)
 	}
 
+	now := time.Now()
 	return &entity.ExchangeRate{
 		Result:          "success",
 		BaseCode:        fromCurrency,
 		ConversionRates: conversionRates,
-		FetchedAt:       time.Now(),
+		FetchedAt:       now,
+		Date:            entity.StartOfDay(now),
+		Provider:        providerName,
 	}, nil
 
 	//HardCoded For now
@@ -51,6 +60,8 @@ func (m *MockExchangeRateRepository) GetRateByDate(ctx context.Context, fromCurr
 			toCurrency: rate,
 		},
 		FetchedAt: time.Now(),
+		Date:      entity.StartOfDay(date),
+		Provider:  providerName,
 	}, nil
 }
 
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    base_code        VARCHAR(16) NOT NULL,
+    rate_date        VARCHAR(10) NOT NULL,
+    provider         VARCHAR(64) NOT NULL,
+    conversion_rates TEXT        NOT NULL,
+    fetched_at       VARCHAR(40) NOT NULL,
+    PRIMARY KEY (base_code, rate_date)
+);
@@ -0,0 +1,205 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	exchange "exchange-rate-service/internal/domain/exchange"
+	"exchange-rate-service/pkg/logger"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+const dateLayout = "2006-01-02"
+
+type sqlRepository struct {
+	db *sql.DB
+}
+
+// Open connects to the history store. driver is "sqlite" or "postgres".
+func Open(driver, dsn string) (*sql.DB, error) {
+	switch driver {
+	case "sqlite":
+	case "postgres":
+		driver = "pgx"
+	default:
+		return nil, fmt.Errorf("unsupported history store driver %q", driver)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history store: %w", err)
+	}
+	if driver == "sqlite" {
+		// SQLite allows a single writer; serialize access instead of
+		// surfacing SQLITE_BUSY to callers.
+		db.SetMaxOpenConns(1)
+	}
+	return db, nil
+}
+
+// NewSQLRepository applies pending migrations and returns a repository that
+// keeps every daily table it is given, keyed by base currency and rate date.
+func NewSQLRepository(ctx context.Context, db *sql.DB) (exchange.ExchangeRateCacheRepository, error) {
+	if err := migrate(ctx, db); err != nil {
+		return nil, err
+	}
+	return &sqlRepository{db: db}, nil
+}
+
+// Close closes the database handle the repository was created with.
+func (r *sqlRepository) Close() error {
+	return r.db.Close()
+}
+
+func (r *sqlRepository) StoreRate(ctx context.Context, rate *exchange.ExchangeRate) error {
+	if rate == nil {
+		return fmt.Errorf("rate cannot be nil")
+	}
+	if rate.Date.IsZero() {
+		return fmt.Errorf("rate for %s has no effective date", rate.BaseCode)
+	}
+
+	rates, err := json.Marshal(rate.ConversionRates)
+	if err != nil {
+		return fmt.Errorf("failed to encode conversion rates: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO exchange_rates (base_code, rate_date, provider, conversion_rates, fetched_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (base_code, rate_date) DO UPDATE SET
+			provider = excluded.provider,
+			conversion_rates = excluded.conversion_rates,
+			fetched_at = excluded.fetched_at`,
+		rate.BaseCode,
+		rate.Date.Format(dateLayout),
+		rate.Provider,
+		string(rates),
+		rate.FetchedAt.UTC().Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store rate in history: %w", err)
+	}
+	logger.Infof("Stored historical rate for %s on %s", rate.BaseCode, rate.Date.Format(dateLayout))
+	return nil
+}
+
+func (r *sqlRepository) GetCachedRate(ctx context.Context, fromCurrency, toCurrency string, date time.Time) (*exchange.ExchangeRate, error) {
+	var provider, rates, fetchedAt string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT provider, conversion_rates, fetched_at
+		FROM exchange_rates
+		WHERE base_code = $1 AND rate_date = $2`,
+		fromCurrency,
+		date.Format(dateLayout),
+	).Scan(&provider, &rates, &fetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("rate not found in history for date %s", date.Format(dateLayout))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+
+	rate := &exchange.ExchangeRate{
+		Result:   "success",
+		BaseCode: fromCurrency,
+		Date:     time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+		Provider: provider,
+	}
+	if err := json.Unmarshal([]byte(rates), &rate.ConversionRates); err != nil {
+		return nil, fmt.Errorf("failed to decode conversion rates: %w", err)
+	}
+	if rate.FetchedAt, err = time.Parse(time.RFC3339Nano, fetchedAt); err != nil {
+		return nil, fmt.Errorf("failed to decode fetched_at: %w", err)
+	}
+	return rate, nil
+}
+
+// CacheRate stores the rate permanently; history never expires so ttl is ignored.
+func (r *sqlRepository) CacheRate(ctx context.Context, rate *exchange.ExchangeRate, ttl time.Duration) error {
+	return r.StoreRate(ctx, rate)
+}
+
+func migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER     NOT NULL PRIMARY KEY,
+			applied_at VARCHAR(40) NOT NULL
+		)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version, err := migrationVersion(name)
+		if err != nil {
+			return err
+		}
+
+		var applied int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = $1`, version).Scan(&applied); err != nil {
+			return fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		if applied > 0 {
+			continue
+		}
+
+		statement, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if err := applyMigration(ctx, db, version, string(statement)); err != nil {
+			return fmt.Errorf("migration %s failed: %w", name, err)
+		}
+		logger.Infof("Applied history store migration %s", name)
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, version int, statement string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, statement); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, applied_at) VALUES ($1, $2)`,
+		version, time.Now().UTC().Format(time.RFC3339Nano),
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func migrationVersion(name string) (int, error) {
+	base := strings.TrimPrefix(name, "migrations/")
+	prefix, _, _ := strings.Cut(base, "_")
+	version, err := strconv.Atoi(prefix)
+	if err != nil {
+		return 0, fmt.Errorf("migration %s has no numeric version prefix", name)
+	}
+	return version, nil
+}
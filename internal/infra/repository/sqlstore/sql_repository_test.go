@@ -0,0 +1,112 @@
+package sqlstore
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+func newTestRepository(t *testing.T) (exchange.ExchangeRateCacheRepository, string) {
+	t.Helper()
+	dsn := "file:" + filepath.Join(t.TempDir(), "history.db")
+	db, err := Open("sqlite", dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	repo, err := NewSQLRepository(context.Background(), db)
+	if err != nil {
+		t.Fatalf("new repository: %v", err)
+	}
+	return repo, dsn
+}
+
+func TestSQLRepository_StoreAndGet(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	ctx := context.Background()
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	err := repo.StoreRate(ctx, &exchange.ExchangeRate{
+		Result:          "success",
+		BaseCode:        "USD",
+		ConversionRates: map[string]float64{"EUR": 0.92},
+		FetchedAt:       time.Now(),
+		Date:            date,
+		Provider:        "exchangerate-api",
+	})
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	rate, err := repo.GetCachedRate(ctx, "USD", "EUR", date)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if rate.ConversionRates["EUR"] != 0.92 || rate.Provider != "exchangerate-api" || !rate.Date.Equal(date) {
+		t.Fatalf("unexpected rate: %+v", rate)
+	}
+
+	if _, err := repo.GetCachedRate(ctx, "USD", "EUR", date.AddDate(0, 0, 1)); err == nil {
+		t.Fatal("expected miss for a date that was never stored")
+	}
+}
+
+func TestSQLRepository_RejectsUndatedRate(t *testing.T) {
+	repo, _ := newTestRepository(t)
+
+	err := repo.StoreRate(context.Background(), &exchange.ExchangeRate{BaseCode: "USD"})
+	if err == nil {
+		t.Fatal("expected error for rate without a date")
+	}
+}
+
+func TestSQLRepository_CloseReleasesDatabase(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	closer, ok := repo.(io.Closer)
+	if !ok {
+		t.Fatal("expected the history store to be closable")
+	}
+	if err := closer.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	err := repo.StoreRate(context.Background(), &exchange.ExchangeRate{BaseCode: "USD", Date: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)})
+	if err == nil {
+		t.Fatal("expected writes to fail once the store is closed")
+	}
+}
+
+func TestSQLRepository_PersistsAcrossReopen(t *testing.T) {
+	repo, dsn := newTestRepository(t)
+	ctx := context.Background()
+	date := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	if err := repo.StoreRate(ctx, &exchange.ExchangeRate{
+		BaseCode:        "EUR",
+		ConversionRates: map[string]float64{"USD": 1.08},
+		FetchedAt:       time.Now(),
+		Date:            date,
+		Provider:        "exchangerate-api",
+	}); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	db, err := Open("sqlite", dsn)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	reopened, err := NewSQLRepository(ctx, db)
+	if err != nil {
+		t.Fatalf("migrations should be idempotent: %v", err)
+	}
+
+	if _, err := reopened.GetCachedRate(ctx, "EUR", "USD", date); err != nil {
+		t.Fatalf("expected stored rate after reopen: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package exchange
+
+import (
+	"context"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+// ConvertBatch converts every item independently: a failing item reports
+// its error without failing the rest. Rate tables are resolved once per
+// distinct base and day of the batch.
+func (s *exchangeRateUseCase) ConvertBatch(ctx context.Context, items []domain_exchange.BatchConversionItem) ([]domain_exchange.BatchConversionResult, error) {
+	if len(items) == 0 {
+		return nil, domain_exchange.Errorf(domain_exchange.ErrInvalidRequest, "batch must contain at least one item")
+	}
+	if s.maxBatchSize > 0 && len(items) > s.maxBatchSize {
+		return nil, domain_exchange.Errorf(domain_exchange.ErrInvalidRequest, "batch of %d items exceeds the limit of %d", len(items), s.maxBatchSize)
+	}
+
+	tables := make(tableMemo)
+	results := make([]domain_exchange.BatchConversionResult, len(items))
+	for i, item := range items {
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i] = s.convertBatchItem(ctx, item, tables)
+	}
+	return results, nil
+}
+
+func (s *exchangeRateUseCase) convertBatchItem(ctx context.Context, item domain_exchange.BatchConversionItem, tables tableMemo) domain_exchange.BatchConversionResult {
+	if err := s.validateConversion(item.From, item.To, item.Amount, item.Date); err != nil {
+		return domain_exchange.BatchConversionResult{Err: err}
+	}
+
+	table, err := s.memoTable(ctx, tables, item.From, item.To, item.Date)
+	if err != nil {
+		return domain_exchange.BatchConversionResult{Err: err}
+	}
+
+	path := domain_exchange.ConversionPath{item.From, item.To}
+	rate, found := crossRate(table, item.From, item.To)
+	if !found {
+		// Cross-type pairs are not in the base table; resolve them the slow way.
+		q, err := s.resolveRate(ctx, item.From, item.To, item.Date)
+		if err != nil {
+			return domain_exchange.BatchConversionResult{Err: err}
+		}
+		rate, path = q.rate, q.path
+	}
+
+	rateDecimal := domain_exchange.NewDecimalFromFloat(rate)
+	return domain_exchange.BatchConversionResult{
+		Converted: s.convert(item.Amount, rateDecimal, item.To),
+		Rate:      rateDecimal,
+		Path:      path,
+	}
+}
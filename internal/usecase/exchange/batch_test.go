@@ -0,0 +1,60 @@
+package exchange
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+func TestConvertBatch_ResolvesEachBaseAndDayOnce(t *testing.T) {
+	external := &fakeExternalRepo{rates: map[string]float64{"USD": 1, "EUR": 0.5, "GBP": 0.25, "JPY": 150}}
+	uc := NewExchangeRateUseCase(external, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90})
+	amount, _ := domain_exchange.ParseDecimal("10")
+	date := time.Now().AddDate(0, 0, -2)
+
+	items := []domain_exchange.BatchConversionItem{
+		{From: "USD", To: "EUR", Amount: amount},
+		{From: "USD", To: "GBP", Amount: amount},
+		{From: "USD", To: "EUR", Amount: amount, Date: date},
+		{From: "USD", To: "JPY", Amount: amount, Date: date.Add(time.Hour)},
+		{From: "USD", To: "XAU", Amount: amount},
+	}
+	results, err := uc.ConvertBatch(context.Background(), items)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != len(items) {
+		t.Fatalf("expected %d results, got %d", len(items), len(results))
+	}
+	if results[0].Converted.String() != "5.00" || results[1].Converted.String() != "2.50" {
+		t.Fatalf("unexpected conversions %s and %s", results[0].Converted, results[1].Converted)
+	}
+	if results[3].Err != nil || results[3].Converted.String() != "1500" {
+		t.Fatalf("unexpected historical conversion: %+v", results[3])
+	}
+	if results[4].Err == nil {
+		t.Fatal("expected the unsupported currency to fail on its own")
+	}
+	if external.latestCalls != 1 || external.dateCalls != 1 {
+		t.Fatalf("expected one latest and one historical fetch, got %d and %d", external.latestCalls, external.dateCalls)
+	}
+}
+
+func TestConvertBatch_EnforcesMaxBatchSize(t *testing.T) {
+	external := &fakeExternalRepo{rates: map[string]float64{"EUR": 0.5}}
+	uc := NewExchangeRateUseCase(external, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90, MaxBatchSize: 1})
+	amount, _ := domain_exchange.ParseDecimal("1")
+
+	items := []domain_exchange.BatchConversionItem{
+		{From: "USD", To: "EUR", Amount: amount},
+		{From: "USD", To: "EUR", Amount: amount},
+	}
+	if _, err := uc.ConvertBatch(context.Background(), items); err == nil {
+		t.Fatal("expected an oversized batch to be rejected")
+	}
+	if external.calls() != 0 {
+		t.Fatal("expected no upstream request for a rejected batch")
+	}
+}
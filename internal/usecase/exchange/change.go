@@ -0,0 +1,76 @@
+package exchange
+
+import (
+	"context"
+	"math"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+// GetRateChange reports how the from→to rate moved between start and end,
+// computed over the days of the time series that have a rate. Volatility
+// only uses returns between consecutive calendar days, so a gap in the data
+// is not mistaken for a single day's move.
+func (s *exchangeRateUseCase) GetRateChange(ctx context.Context, from, to string, start, end time.Time) (*domain_exchange.RateChange, error) {
+	series, err := s.GetTimeSeries(ctx, from, to, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(series.Points) < 2 {
+		return nil, domain_exchange.Errorf(domain_exchange.ErrRateUnavailable,
+			"at least 2 days with a %s to %s rate are needed between %s and %s, found %d",
+			from, to, series.Start.Format(dayLayout), series.End.Format(dayLayout), len(series.Points))
+	}
+
+	first, last := series.Points[0], series.Points[len(series.Points)-1]
+	change := &domain_exchange.RateChange{
+		From:           from,
+		To:             to,
+		StartDate:      first.Date,
+		EndDate:        last.Date,
+		StartRate:      first.Rate,
+		EndRate:        last.Rate,
+		AbsoluteChange: last.Rate - first.Rate,
+		PercentChange:  (last.Rate - first.Rate) / first.Rate * 100,
+		Min:            first.Rate,
+		Max:            first.Rate,
+		Points:         len(series.Points),
+		MissingDays:    series.MissingDays,
+	}
+
+	var sum float64
+	returns := make([]float64, 0, len(series.Points)-1)
+	for i, point := range series.Points {
+		sum += point.Rate
+		change.Min = math.Min(change.Min, point.Rate)
+		change.Max = math.Max(change.Max, point.Rate)
+		if i == 0 {
+			continue
+		}
+		if previous := series.Points[i-1]; previous.Date.AddDate(0, 0, 1).Equal(point.Date) {
+			returns = append(returns, point.Rate/previous.Rate-1)
+		}
+	}
+	change.Mean = sum / float64(len(series.Points))
+	change.Volatility = sampleStdDev(returns)
+	return change, nil
+}
+
+// sampleStdDev is zero for fewer than two values.
+func sampleStdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	var mean float64
+	for _, value := range values {
+		mean += value
+	}
+	mean /= float64(len(values))
+
+	var squares float64
+	for _, value := range values {
+		squares += (value - mean) * (value - mean)
+	}
+	return math.Sqrt(squares / float64(len(values)-1))
+}
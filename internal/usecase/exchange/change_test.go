@@ -0,0 +1,103 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+// seedDailyRates caches one USD table per day starting at start.
+func seedDailyRates(cacheRepo *fakeCacheRepo, start time.Time, eurRates ...float64) {
+	for i, rate := range eurRates {
+		cacheRepo.StoreRate(context.Background(), &domain_exchange.ExchangeRate{
+			BaseCode:        "USD",
+			ConversionRates: map[string]float64{"EUR": rate},
+			Date:            start.AddDate(0, 0, i),
+		})
+	}
+}
+
+func TestGetRateChange_Statistics(t *testing.T) {
+	start := truncateToDay(time.Now().AddDate(0, 0, -4))
+	end := truncateToDay(time.Now().AddDate(0, 0, -1))
+	cacheRepo := newFakeCacheRepo()
+	seedDailyRates(cacheRepo, start, 1, 2, 1, 2)
+	uc := NewExchangeRateUseCase(&fakeExternalRepo{}, cacheRepo, nil, testRegistry, Options{MaxHistoricalDays: 90})
+
+	change, err := uc.GetRateChange(context.Background(), "USD", "EUR", start, end)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if change.StartRate != 1 || change.EndRate != 2 || change.AbsoluteChange != 1 || change.PercentChange != 100 {
+		t.Fatalf("unexpected change %+v", change)
+	}
+	if change.Min != 1 || change.Max != 2 || change.Mean != 1.5 || change.Points != 4 {
+		t.Fatalf("unexpected range statistics %+v", change)
+	}
+	// Daily returns are +100%, -50% and +100%.
+	if math.Abs(change.Volatility-math.Sqrt(0.75)) > 1e-12 {
+		t.Fatalf("expected volatility %v, got %v", math.Sqrt(0.75), change.Volatility)
+	}
+}
+
+func TestGetRateChange_UsesFirstAndLastAvailableDays(t *testing.T) {
+	start := truncateToDay(time.Now().AddDate(0, 0, -4))
+	end := truncateToDay(time.Now().AddDate(0, 0, -1))
+	external := &fakeExternalRepo{
+		historicalRates: map[string]float64{"USD": 1, "EUR": 0.5},
+		missingDays:     map[string]bool{start.Format(dayLayout): true},
+	}
+	cacheRepo := newFakeCacheRepo()
+	seedDailyRates(cacheRepo, end, 0.4)
+	uc := NewExchangeRateUseCase(external, cacheRepo, nil, testRegistry, Options{MaxHistoricalDays: 90})
+
+	change, err := uc.GetRateChange(context.Background(), "USD", "EUR", start, end)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !change.StartDate.Equal(start.AddDate(0, 0, 1)) || !change.EndDate.Equal(end) {
+		t.Fatalf("unexpected effective range %v to %v", change.StartDate, change.EndDate)
+	}
+	if math.Abs(change.PercentChange+20) > 1e-9 || len(change.MissingDays) != 1 {
+		t.Fatalf("unexpected change %+v", change)
+	}
+}
+
+func TestGetRateChange_VolatilitySkipsReturnsAcrossGaps(t *testing.T) {
+	start := truncateToDay(time.Now().AddDate(0, 0, -5))
+	end := truncateToDay(time.Now().AddDate(0, 0, -1))
+	external := &fakeExternalRepo{missingDays: map[string]bool{start.AddDate(0, 0, 2).Format(dayLayout): true}}
+	cacheRepo := newFakeCacheRepo()
+	seedDailyRates(cacheRepo, start, 1, 2)
+	seedDailyRates(cacheRepo, start.AddDate(0, 0, 3), 4, 2)
+	uc := NewExchangeRateUseCase(external, cacheRepo, nil, testRegistry, Options{MaxHistoricalDays: 90})
+
+	change, err := uc.GetRateChange(context.Background(), "USD", "EUR", start, end)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if change.Points != 4 || len(change.MissingDays) != 1 {
+		t.Fatalf("unexpected points %d and missing days %v", change.Points, change.MissingDays)
+	}
+	// Only the one-day returns +100% and -50% count; the move from 2 to 4
+	// spans the missing day.
+	if want := math.Sqrt(1.125); math.Abs(change.Volatility-want) > 1e-12 {
+		t.Fatalf("expected volatility %v, got %v", want, change.Volatility)
+	}
+}
+
+func TestGetRateChange_NeedsTwoPoints(t *testing.T) {
+	day := truncateToDay(time.Now().AddDate(0, 0, -2))
+	cacheRepo := newFakeCacheRepo()
+	seedDailyRates(cacheRepo, day, 0.9)
+	uc := NewExchangeRateUseCase(&fakeExternalRepo{}, cacheRepo, nil, testRegistry, Options{MaxHistoricalDays: 90})
+
+	_, err := uc.GetRateChange(context.Background(), "USD", "EUR", day, day)
+	if !errors.Is(err, domain_exchange.ErrRateUnavailable) {
+		t.Fatalf("expected ErrRateUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package exchange
+
+import (
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+func (s *exchangeRateUseCase) ListCurrencies(currencyType string) ([]domain_exchange.CurrencyInfo, error) {
+	switch currencyType {
+	case "", domain_exchange.CurrencyTypeFiat, domain_exchange.CurrencyTypeCrypto, domain_exchange.CurrencyTypeMetal:
+	default:
+		return nil, domain_exchange.Errorf(domain_exchange.ErrInvalidRequest, "unknown currency type %s", currencyType)
+	}
+
+	currencies := s.registry.List()
+	infos := make([]domain_exchange.CurrencyInfo, 0, len(currencies))
+	for _, currency := range currencies {
+		if currencyType != "" && currency.Type != currencyType {
+			continue
+		}
+		infos = append(infos, s.currencyInfo(currency))
+	}
+	return infos, nil
+}
+
+func (s *exchangeRateUseCase) GetCurrency(code string) (*domain_exchange.CurrencyInfo, error) {
+	currency, exists := s.registry.Get(code)
+	if !exists {
+		return nil, domain_exchange.Errorf(domain_exchange.ErrCurrencyNotFound, "currency %s is not supported", code)
+	}
+	info := s.currencyInfo(currency)
+	return &info, nil
+}
+
+func (s *exchangeRateUseCase) currencyInfo(currency domain_exchange.Currency) domain_exchange.CurrencyInfo {
+	var provider string
+	if resolver, ok := s.externalRepo.(domain_exchange.ProviderResolver); ok {
+		provider = resolver.ProviderFor(currency.Code)
+	} else {
+		provider = s.externalRepo.Name()
+	}
+
+	// History is served no further back than maxHistoricalDays regardless of
+	// how far the upstream archive goes.
+	return domain_exchange.CurrencyInfo{
+		Currency:          currency,
+		Provider:          provider,
+		OldestAllowedDate: oldestAllowedDay(time.Now(), s.maxHistoricalDays),
+	}
+}
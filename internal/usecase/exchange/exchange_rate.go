@@ -2,83 +2,170 @@ package exchange
 
 import (
 	"context"
-	"errors"
-	"fmt"
 	"sync"
 	"time"
 
 	domain_exchange "exchange-rate-service/internal/domain/exchange"
-	"exchange-rate-service/pkg/logger"
 )
 
 func NewExchangeRateUsecase(usecase domain_exchange.ExchangeRateUsercase) domain_exchange.ExchangeRateUsercase {
 	return usecase
 }
 
+// Options holds the tunables of the exchange rate use case.
+type Options struct {
+	MaxHistoricalDays int
+	RoundingMode      domain_exchange.RoundingMode
+	// MaxStaleAge is how old a latest table may get while it is served
+	// during revalidation or an upstream outage; zero disables stale serving.
+	MaxStaleAge time.Duration
+	// LatestTTL and CryptoLatestTTL are how long a latest table counts as
+	// fresh; older tables handed over by the providers are marked stale.
+	LatestTTL       time.Duration
+	CryptoLatestTTL time.Duration
+	// PivotCurrency, when set, derives the tables of every currency of the
+	// same type from the pivot's table instead of fetching their own.
+	// CompareDirect additionally fetches direct tables during refreshes to
+	// report how far derived rates stray from them.
+	PivotCurrency        string
+	CompareDirect        bool
+	PivotMaxDeviationBps float64
+	// RefreshConcurrency bounds the upstream requests of a refresh run and
+	// RefreshTimeout is the deadline for the whole run.
+	RefreshConcurrency int
+	RefreshTimeout     time.Duration
+	// MaxBatchSize caps the items of a ConvertBatch call; zero means no cap.
+	MaxBatchSize int
+}
+
 type exchangeRateUseCase struct {
-	externalRepo      domain_exchange.ExchangeRateExternalRepository
-	cacheRepo         domain_exchange.ExchangeRateCacheRepository
-	maxHistoricalDays int
+	externalRepo         domain_exchange.ExchangeRateExternalRepository
+	cacheRepo            domain_exchange.ExchangeRateCacheRepository
+	historyRepo          domain_exchange.ExchangeRateCacheRepository
+	registry             domain_exchange.CurrencyRegistry
+	maxHistoricalDays    int
+	roundingMode         domain_exchange.RoundingMode
+	maxStaleAge          time.Duration
+	latestTTL            time.Duration
+	cryptoLatestTTL      time.Duration
+	refreshWorkers       int
+	pivot                string
+	compareDirect        bool
+	pivotMaxDeviationBps float64
+	refreshTimeout       time.Duration
+	maxBatchSize         int
+
+	latestMu   sync.Mutex
+	lastKnown  map[string]*domain_exchange.ExchangeRate
+	refreshing map[string]bool
+
+	flights flightGroup
 }
 
 func NewExchangeRateUseCase(
 	externalRepo domain_exchange.ExchangeRateExternalRepository,
 	cacheRepo domain_exchange.ExchangeRateCacheRepository,
-	maxHistoricalDays int,
+	historyRepo domain_exchange.ExchangeRateCacheRepository,
+	registry domain_exchange.CurrencyRegistry,
+	options Options,
 ) domain_exchange.ExchangeRateUsercase {
 	return &exchangeRateUseCase{
-		externalRepo:      externalRepo,
-		cacheRepo:         cacheRepo,
-		maxHistoricalDays: maxHistoricalDays,
+		externalRepo:         externalRepo,
+		cacheRepo:            cacheRepo,
+		historyRepo:          historyRepo,
+		registry:             registry,
+		maxHistoricalDays:    options.MaxHistoricalDays,
+		roundingMode:         options.RoundingMode,
+		maxStaleAge:          options.MaxStaleAge,
+		latestTTL:            options.LatestTTL,
+		cryptoLatestTTL:      options.CryptoLatestTTL,
+		refreshWorkers:       options.RefreshConcurrency,
+		pivot:                options.PivotCurrency,
+		compareDirect:        options.CompareDirect,
+		pivotMaxDeviationBps: options.PivotMaxDeviationBps,
+		refreshTimeout:       options.RefreshTimeout,
+		maxBatchSize:         options.MaxBatchSize,
+		lastKnown:            make(map[string]*domain_exchange.ExchangeRate),
+		refreshing:           make(map[string]bool),
 	}
 }
 
 func (s *exchangeRateUseCase) ValidateCurrencies(from, to string) error {
 	if from == "" || to == "" {
-		return errors.New("from and to currencies are required")
+		return domain_exchange.Errorf(domain_exchange.ErrInvalidRequest, "from and to currencies are required")
 	}
-	if _, exists := domain_exchange.SupportedCurrencies[from]; !exists {
-		return fmt.Errorf("currency %s is not supported", from)
+	if _, exists := s.registry.Get(from); !exists {
+		return domain_exchange.Errorf(domain_exchange.ErrUnsupportedCurrency, "currency %s is not supported", from)
 	}
-	if _, exists := domain_exchange.SupportedCurrencies[to]; !exists {
-		return fmt.Errorf("currency %s is not supported", to)
+	if _, exists := s.registry.Get(to); !exists {
+		return domain_exchange.Errorf(domain_exchange.ErrUnsupportedCurrency, "currency %s is not supported", to)
 	}
 	return nil
 }
 
+// oldestAllowedDay is the first day historical requests may reach: the whole
+// day maxHistoricalDays back counts, whatever the time of day now.
+func oldestAllowedDay(now time.Time, maxHistoricalDays int) time.Time {
+	return truncateToDay(now).AddDate(0, 0, -maxHistoricalDays)
+}
+
 func (s *exchangeRateUseCase) ValidateDate(date time.Time, maxHistoricalDays int) error {
 	now := time.Now()
-	maxPastDate := now.AddDate(0, 0, -maxHistoricalDays)
 	if date.After(now) {
-		return errors.New("date cannot be in the future")
+		return domain_exchange.Errorf(domain_exchange.ErrDateOutOfRange, "date cannot be in the future")
 	}
-	if date.Before(maxPastDate) {
-		return fmt.Errorf("date cannot be older than %d days", maxHistoricalDays)
+	if date.Before(oldestAllowedDay(now, maxHistoricalDays)) {
+		return domain_exchange.Errorf(domain_exchange.ErrDateOutOfRange, "date cannot be older than %d days", maxHistoricalDays)
 	}
 	return nil
 }
 
-func (s *exchangeRateUseCase) GetLatestRate(ctx context.Context, from, to string) (float64, error) {
+func (s *exchangeRateUseCase) GetLatestRate(ctx context.Context, from, to string) (*domain_exchange.PairRate, error) {
 	if err := s.ValidateCurrencies(from, to); err != nil {
-		return 0, err
-	}
-	if cachedRate, err := s.cacheRepo.GetCachedRate(ctx, from, to, time.Now()); err == nil && cachedRate != nil {
-		if rate, exists := cachedRate.ConversionRates[to]; exists {
-			logger.Infof("Cache hit for latest rate %s to %s", from, to)
-			return rate, nil
-		}
+		return nil, err
 	}
-	rate, err := s.externalRepo.GetLatestRate(ctx, from)
+	q, err := s.resolveRate(ctx, from, to, time.Time{})
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch latest rate: %w", err)
+		return nil, err
 	}
-	if err := s.cacheRepo.StoreRate(ctx, rate); err != nil {
-		logger.Errorf("Failed to cache rate: %v", err)
+	return &domain_exchange.PairRate{
+		From:      from,
+		To:        to,
+		Rate:      q.rate,
+		Path:      q.path,
+		Date:      q.date,
+		FetchedAt: q.fetchedAt,
+		Provider:  joinProviders(q),
+		Stale:     q.stale,
+	}, nil
+}
+
+func (s *exchangeRateUseCase) GetLatestRates(ctx context.Context, from string) (*domain_exchange.ExchangeRate, error) {
+	if err := s.ValidateCurrencies(from, from); err != nil {
+		return nil, err
 	}
-	if conversionRate, exists := rate.ConversionRates[to]; exists {
-		return conversionRate, nil
+	rate, err := s.latestTable(ctx, from)
+	if err != nil {
+		return nil, err
 	}
-	return 0, fmt.Errorf("conversion rate from %s to %s not found", from, to)
+
+	// Only expose targets the service can actually convert to.
+	currencies := s.registry.List()
+	supported := make(map[string]float64, len(currencies))
+	for _, currency := range currencies {
+		if conversionRate, exists := rate.ConversionRates[currency.Code]; exists {
+			supported[currency.Code] = conversionRate
+		}
+	}
+	return &domain_exchange.ExchangeRate{
+		Result:          rate.Result,
+		BaseCode:        rate.BaseCode,
+		ConversionRates: supported,
+		FetchedAt:       rate.FetchedAt,
+		Provider:        rate.Provider,
+		Stale:           rate.Stale,
+		Pivot:           rate.Pivot,
+	}, nil
 }
 
 func (s *exchangeRateUseCase) getHistoricalRate(ctx context.Context, from, to string, date time.Time) (float64, error) {
@@ -88,69 +175,88 @@ func (s *exchangeRateUseCase) getHistoricalRate(ctx context.Context, from, to st
 	if err := s.ValidateDate(date, s.maxHistoricalDays); err != nil {
 		return 0, err
 	}
-	if cachedRate, err := s.cacheRepo.GetCachedRate(ctx, from, to, date); err == nil && cachedRate != nil {
-		if rate, exists := cachedRate.ConversionRates[to]; exists {
-			logger.Infof("Cache hit for historical rate %s to %s on %s", from, to, date.Format("2006-01-02"))
-			return rate, nil
-		}
-	}
-	rate, err := s.externalRepo.GetRateByDate(ctx, from, to, date)
-	if err != nil {
-		return 0, fmt.Errorf("failed to fetch historical rate: %w", err)
-	}
-	if err := s.cacheRepo.StoreRate(ctx, rate); err != nil {
-		logger.Errorf("Failed to cache historical rate: %v", err)
-	}
-	if conversionRate, exists := rate.ConversionRates[to]; exists {
-		return conversionRate, nil
-	}
-	return 0, fmt.Errorf("conversion rate from %s to %s not found for date %s", from, to, date.Format("2006-01-02"))
+	q, err := s.resolveRate(ctx, from, to, date)
+	return q.rate, err
 }
 
-func (s *exchangeRateUseCase) resolveRateByDate(ctx context.Context, from, to string, targetDate time.Time) (float64, error) {
-	if targetDate.IsZero() {
-		return s.GetLatestRate(ctx, from, to)
-	}
-	return s.getHistoricalRate(ctx, from, to, targetDate)
+// fetchHistoricalTable asks the upstream for the table of date and stores it.
+// Concurrent callers for the same base and day share a single request.
+func (s *exchangeRateUseCase) fetchHistoricalTable(ctx context.Context, from, to string, date time.Time) (*domain_exchange.ExchangeRate, error) {
+	key := from + ":" + domain_exchange.StartOfDay(date).Format(dayLayout)
+	return s.flights.do(ctx, "historical", key, func(ctx context.Context) (*domain_exchange.ExchangeRate, error) {
+		rate, err := s.externalRepo.GetRateByDate(ctx, from, to, date)
+		if err != nil {
+			return nil, domain_exchange.Errorf(domain_exchange.ErrUpstreamFailure, "failed to fetch historical rate: %w", err)
+		}
+		s.storeHistoricalTable(ctx, rate)
+		return rate, nil
+	})
 }
 
-func (s *exchangeRateUseCase) ConvertAmount(ctx context.Context, from, to string, amount float64, fromDate, toDate time.Time) (float64, float64, float64, float64, error) {
-	if amount <= 0 {
-		return 0, 0, 0, 0, errors.New("amount must be greater than 0")
+func (s *exchangeRateUseCase) ConvertAmount(ctx context.Context, request domain_exchange.ConversionRequest) (*domain_exchange.Conversion, error) {
+	if err := s.validateConversion(request.From, request.To, request.Amount, request.FromDate, request.ToDate); err != nil {
+		return nil, err
 	}
 
-	fromRate, err := s.resolveRateByDate(ctx, from, to, fromDate)
+	fromQuote, err := s.resolveRate(ctx, request.From, request.To, request.FromDate)
 	if err != nil {
-		return 0, 0, 0, 0, err
+		return nil, err
 	}
 
-	toRate, err := s.resolveRateByDate(ctx, from, to, toDate)
+	// Both dates go through the same currencies so the amounts compare.
+	toQuote, err := s.rateAlongPath(ctx, fromQuote.path, request.ToDate)
 	if err != nil {
-		return 0, 0, 0, 0, err
+		return nil, err
 	}
-	convertedAmountAtFromDate := amount * fromRate
-	convertedAmountAtToDate := amount * toRate
-	return convertedAmountAtFromDate, convertedAmountAtToDate, fromRate, toRate, nil
+
+	fromRate := domain_exchange.NewDecimalFromFloat(fromQuote.rate)
+	toRate := domain_exchange.NewDecimalFromFloat(toQuote.rate)
+	return &domain_exchange.Conversion{
+		From:            request.From,
+		To:              request.To,
+		OriginalAmount:  request.Amount,
+		ConvertedAtFrom: s.convert(request.Amount, fromRate, request.To),
+		ConvertedAtTo:   s.convert(request.Amount, toRate, request.To),
+		FromRate:        fromRate,
+		ToRate:          toRate,
+		FromDate:        fromQuote.date,
+		ToDate:          toQuote.date,
+		Provider:        joinProviders(fromQuote, toQuote),
+		Stale:           fromQuote.stale || toQuote.stale,
+		Path:            fromQuote.path,
+	}, nil
 }
 
-func (s *exchangeRateUseCase) RefreshRates(ctx context.Context) error {
-	logger.Info("Starting rate refresh for all supported currencies")
-	var wg sync.WaitGroup
-	for baseCurrency := range domain_exchange.SupportedCurrencies {
-		wg.Add(1)
-		go func() {
-			rate, err := s.externalRepo.GetLatestRate(ctx, baseCurrency)
-			if err != nil {
-				logger.Errorf("Failed to refresh rate for %s: %v", baseCurrency, err)
-				wg.Done()
-				return
-			}
-			if err := s.cacheRepo.StoreRate(ctx, rate); err != nil {
-				logger.Errorf("Failed to cache refreshed rate for %s: %v", baseCurrency, err)
-			}
-			wg.Done()
-		}()
-	}
-	logger.Info("Rate refresh completed")
+// validateConversion checks a conversion request before any rate is looked
+// up; zero dates stand for the latest rates.
+func (s *exchangeRateUseCase) validateConversion(from, to string, amount domain_exchange.Decimal, dates ...time.Time) error {
+	if amount.Sign() <= 0 {
+		return domain_exchange.Errorf(domain_exchange.ErrInvalidRequest, "amount must be greater than 0")
+	}
+	if err := s.ValidateCurrencies(from, to); err != nil {
+		return err
+	}
+	// Trailing zeros carry no precision: 10.00 JPY is a whole amount.
+	if units := s.minorUnits(from); !amount.Round(units, domain_exchange.RoundDown).Equal(amount) {
+		return domain_exchange.Errorf(domain_exchange.ErrInvalidRequest, "amount has more than %d decimal places allowed for %s", units, from)
+	}
+	for _, date := range dates {
+		if date.IsZero() {
+			continue
+		}
+		if err := s.ValidateDate(date, s.maxHistoricalDays); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// convert applies rate to amount, rounded to the minor units of to.
+func (s *exchangeRateUseCase) convert(amount, rate domain_exchange.Decimal, to string) domain_exchange.Decimal {
+	return amount.Mul(rate).Round(s.minorUnits(to), s.roundingMode)
+}
+
+func (s *exchangeRateUseCase) minorUnits(code string) int32 {
+	currency, _ := s.registry.Get(code)
+	return currency.MinorUnits
+}
@@ -1 +1,696 @@
 package exchange
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+	"exchange-rate-service/internal/infra/registry"
+	"exchange-rate-service/internal/infra/repository/inmemory"
+	"exchange-rate-service/pkg/cache"
+	"exchange-rate-service/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var testRegistry = registry.NewStaticRegistry(domain_exchange.DefaultCurrencies)
+
+type fakeExternalRepo struct {
+	mu              sync.Mutex
+	rates           map[string]float64
+	historicalRates map[string]float64
+	missingDays     map[string]bool
+	latestErr       error
+	rangeErr        error
+	latestGate      chan struct{}
+	failingBases    map[string]bool
+	latestCalls     int
+	latestBases     []string
+	inFlight        int
+	maxInFlight     int
+	dateCalls       int
+	rangeCalls      int
+}
+
+func (f *fakeExternalRepo) Name() string {
+	return "fake"
+}
+
+func (f *fakeExternalRepo) GetLatestRate(ctx context.Context, fromCurrency string) (*domain_exchange.ExchangeRate, error) {
+	f.mu.Lock()
+	f.latestCalls++
+	f.latestBases = append(f.latestBases, fromCurrency)
+	f.inFlight++
+	f.maxInFlight = max(f.maxInFlight, f.inFlight)
+	err := f.latestErr
+	if f.failingBases[fromCurrency] {
+		err = errors.New("no data for " + fromCurrency)
+	}
+	gate := f.latestGate
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	if gate != nil {
+		select {
+		case <-gate:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	} else {
+		// Give concurrent callers a chance to overlap.
+		time.Sleep(time.Millisecond)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &domain_exchange.ExchangeRate{
+		Result:          "success",
+		BaseCode:        fromCurrency,
+		ConversionRates: f.rates,
+		FetchedAt:       time.Now(),
+		Date:            domain_exchange.StartOfDay(time.Now()),
+		Provider:        "fake",
+	}, nil
+}
+
+func (f *fakeExternalRepo) GetRateByDate(ctx context.Context, fromCurrency, toCurrency string, date time.Time) (*domain_exchange.ExchangeRate, error) {
+	f.mu.Lock()
+	f.dateCalls++
+	f.mu.Unlock()
+	if f.missingDays[date.Format(dayLayout)] {
+		return nil, errors.New("no data")
+	}
+	rates := f.historicalRates
+	if rates == nil {
+		rates = f.rates
+	}
+	return &domain_exchange.ExchangeRate{
+		Result:          "success",
+		BaseCode:        fromCurrency,
+		ConversionRates: rates,
+		FetchedAt:       time.Now(),
+		Date:            date,
+		Provider:        "fake",
+	}, nil
+}
+
+func (f *fakeExternalRepo) GetRatesForDateRange(ctx context.Context, fromCurrency, toCurrency string, startDate, endDate time.Time) ([]*domain_exchange.ExchangeRate, error) {
+	f.mu.Lock()
+	f.rangeCalls++
+	f.mu.Unlock()
+	if f.rangeErr != nil {
+		return nil, f.rangeErr
+	}
+	var rates []*domain_exchange.ExchangeRate
+	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+		if rate, err := f.GetRateByDate(ctx, fromCurrency, toCurrency, d); err == nil {
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+type fakeCacheRepo struct {
+	mu    sync.Mutex
+	rates map[string]*domain_exchange.ExchangeRate
+}
+
+func newFakeCacheRepo() *fakeCacheRepo {
+	return &fakeCacheRepo{rates: make(map[string]*domain_exchange.ExchangeRate)}
+}
+
+func (f *fakeCacheRepo) key(base string, date time.Time) string {
+	return base + ":" + domain_exchange.StartOfDay(date).Format(dayLayout)
+}
+
+func (f *fakeCacheRepo) StoreRate(ctx context.Context, rate *domain_exchange.ExchangeRate) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rates[f.key(rate.BaseCode, rate.Date)] = rate
+	return nil
+}
+
+func (f *fakeCacheRepo) GetCachedRate(ctx context.Context, fromCurrency, toCurrency string, date time.Time) (*domain_exchange.ExchangeRate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if rate, exists := f.rates[f.key(fromCurrency, date)]; exists {
+		return rate, nil
+	}
+	return nil, errors.New("rate not found in cache")
+}
+
+func (f *fakeCacheRepo) expireAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rates = make(map[string]*domain_exchange.ExchangeRate)
+}
+
+func (f *fakeCacheRepo) CacheRate(ctx context.Context, rate *domain_exchange.ExchangeRate, ttl time.Duration) error {
+	return f.StoreRate(ctx, rate)
+}
+
+func TestGetTimeSeries_ReportsMissingDays(t *testing.T) {
+	start := truncateToDay(time.Now().AddDate(0, 0, -4))
+	end := truncateToDay(time.Now().AddDate(0, 0, -1))
+	missing := start.AddDate(0, 0, 1)
+
+	external := &fakeExternalRepo{
+		rates:       map[string]float64{"USD": 1, "EUR": 0.9},
+		missingDays: map[string]bool{missing.Format(dayLayout): true},
+	}
+	cacheRepo := newFakeCacheRepo()
+	uc := NewExchangeRateUseCase(external, cacheRepo, nil, testRegistry, Options{MaxHistoricalDays: 90})
+
+	series, err := uc.GetTimeSeries(context.Background(), "USD", "EUR", start, end)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(series.Points) != 3 {
+		t.Fatalf("expected 3 points, got %d", len(series.Points))
+	}
+	if len(series.MissingDays) != 1 || !series.MissingDays[0].Equal(missing) {
+		t.Fatalf("expected %s to be reported missing, got %v", missing.Format(dayLayout), series.MissingDays)
+	}
+}
+
+func TestGetTimeSeries_UpstreamFailureIsNotMissingDays(t *testing.T) {
+	start := truncateToDay(time.Now().AddDate(0, 0, -4))
+	end := truncateToDay(time.Now().AddDate(0, 0, -1))
+	external := &fakeExternalRepo{rangeErr: errors.New("provider down")}
+	uc := NewExchangeRateUseCase(external, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90})
+
+	_, err := uc.GetTimeSeries(context.Background(), "USD", "EUR", start, end)
+	if !errors.Is(err, domain_exchange.ErrUpstreamFailure) {
+		t.Fatalf("expected ErrUpstreamFailure, got %v", err)
+	}
+}
+
+func TestGetTimeSeries_ServesCachedDays(t *testing.T) {
+	start := truncateToDay(time.Now().AddDate(0, 0, -3))
+	end := truncateToDay(time.Now().AddDate(0, 0, -1))
+
+	external := &fakeExternalRepo{rates: map[string]float64{"USD": 1, "EUR": 0.9}}
+	cacheRepo := newFakeCacheRepo()
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		cacheRepo.StoreRate(context.Background(), &domain_exchange.ExchangeRate{
+			BaseCode:        "USD",
+			ConversionRates: map[string]float64{"EUR": 0.8},
+			Date:            d,
+		})
+	}
+	uc := NewExchangeRateUseCase(external, cacheRepo, nil, testRegistry, Options{MaxHistoricalDays: 90})
+
+	series, err := uc.GetTimeSeries(context.Background(), "USD", "EUR", start, end)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if external.rangeCalls != 0 {
+		t.Fatalf("expected no upstream calls, got %d", external.rangeCalls)
+	}
+	if len(series.Points) != 3 || series.Points[0].Rate != 0.8 {
+		t.Fatalf("expected 3 cached points, got %+v", series.Points)
+	}
+}
+
+func TestGetTimeSeries_RejectsRangeBeyondHistory(t *testing.T) {
+	uc := NewExchangeRateUseCase(&fakeExternalRepo{}, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 30})
+
+	_, err := uc.GetTimeSeries(context.Background(), "USD", "EUR", time.Now().AddDate(0, 0, -31), time.Now())
+	if err == nil {
+		t.Fatal("expected error for start date beyond max historical days")
+	}
+}
+
+func TestConvertAmount_RoundsToTargetMinorUnits(t *testing.T) {
+	external := &fakeExternalRepo{rates: map[string]float64{"USD": 1, "JPY": 150.255}}
+	uc := NewExchangeRateUseCase(external, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90})
+	amount, _ := domain_exchange.ParseDecimal("10.10")
+
+	conversion, err := uc.ConvertAmount(context.Background(), domain_exchange.ConversionRequest{From: "USD", To: "JPY", Amount: amount})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if conversion.ConvertedAtFrom.String() != "1518" {
+		t.Fatalf("expected 1518, got %s", conversion.ConvertedAtFrom)
+	}
+	if conversion.FromRate.String() != "150.255" {
+		t.Fatalf("expected rate 150.255, got %s", conversion.FromRate)
+	}
+	if conversion.Provider != "fake" || conversion.Stale || !conversion.FromDate.Equal(domain_exchange.StartOfDay(time.Now())) {
+		t.Fatalf("unexpected conversion metadata: %+v", conversion)
+	}
+}
+
+func TestConvertAmount_RejectsExcessPrecision(t *testing.T) {
+	uc := NewExchangeRateUseCase(&fakeExternalRepo{}, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90})
+	amount, _ := domain_exchange.ParseDecimal("100.5")
+
+	if _, err := uc.ConvertAmount(context.Background(), domain_exchange.ConversionRequest{From: "JPY", To: "USD", Amount: amount}); err == nil {
+		t.Fatal("expected error for fractional JPY amount")
+	}
+}
+
+func TestConvertAmount_AcceptsTrailingZeros(t *testing.T) {
+	external := &fakeExternalRepo{rates: map[string]float64{"USD": 1, "JPY": 150, "EUR": 0.9}}
+	uc := NewExchangeRateUseCase(external, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90})
+
+	for _, request := range []struct{ from, to, amount string }{
+		{from: "JPY", to: "USD", amount: "10.00"},
+		{from: "USD", to: "EUR", amount: "1.000"},
+	} {
+		amount, _ := domain_exchange.ParseDecimal(request.amount)
+		if _, err := uc.ConvertAmount(context.Background(), domain_exchange.ConversionRequest{From: request.from, To: request.to, Amount: amount}); err != nil {
+			t.Fatalf("expected %s %s to be accepted: %v", request.amount, request.from, err)
+		}
+	}
+}
+
+func TestListCurrencies_FiltersByType(t *testing.T) {
+	uc := NewExchangeRateUseCase(&fakeExternalRepo{}, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90})
+
+	currencies, err := uc.ListCurrencies(domain_exchange.CurrencyTypeCrypto)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(currencies) != 1 || currencies[0].Code != "BTC" || currencies[0].Provider != "fake" {
+		t.Fatalf("expected only BTC served by fake, got %+v", currencies)
+	}
+
+	if _, err := uc.ListCurrencies("stock"); err == nil {
+		t.Fatal("expected error for unknown currency type")
+	}
+}
+
+func TestGetCurrency_OldestAllowedDatePassesValidation(t *testing.T) {
+	uc := NewExchangeRateUseCase(&fakeExternalRepo{}, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90})
+
+	currency, err := uc.GetCurrency("EUR")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := uc.ValidateDate(currency.OldestAllowedDate, 90); err != nil {
+		t.Fatalf("expected the advertised oldest date to be accepted: %v", err)
+	}
+	if err := uc.ValidateDate(currency.OldestAllowedDate.AddDate(0, 0, -1), 90); err == nil {
+		t.Fatal("expected the day before the oldest date to be rejected")
+	}
+}
+
+func TestConvertAmount_ConsultsHistoryBeforeUpstream(t *testing.T) {
+	date := truncateToDay(time.Now().AddDate(0, 0, -2))
+	external := &fakeExternalRepo{rates: map[string]float64{"USD": 1, "EUR": 0.9}}
+	history := newFakeCacheRepo()
+	history.StoreRate(context.Background(), &domain_exchange.ExchangeRate{
+		BaseCode:        "USD",
+		ConversionRates: map[string]float64{"EUR": 0.8},
+		Date:            date,
+	})
+	uc := NewExchangeRateUseCase(external, newFakeCacheRepo(), history, testRegistry, Options{MaxHistoricalDays: 90})
+	amount, _ := domain_exchange.ParseDecimal("10")
+
+	conversion, err := uc.ConvertAmount(context.Background(), domain_exchange.ConversionRequest{From: "USD", To: "EUR", Amount: amount, FromDate: date})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if conversion.ConvertedAtFrom.String() != "8.00" {
+		t.Fatalf("expected the stored historical rate to be used, got %s", conversion.ConvertedAtFrom)
+	}
+}
+
+func TestGetTimeSeries_PersistsClosedDays(t *testing.T) {
+	start := truncateToDay(time.Now().AddDate(0, 0, -2))
+	end := truncateToDay(time.Now().AddDate(0, 0, -1))
+	external := &fakeExternalRepo{rates: map[string]float64{"USD": 1, "EUR": 0.9}}
+	history := newFakeCacheRepo()
+	uc := NewExchangeRateUseCase(external, newFakeCacheRepo(), history, testRegistry, Options{MaxHistoricalDays: 90})
+
+	if _, err := uc.GetTimeSeries(context.Background(), "USD", "EUR", start, end); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := uc.GetTimeSeries(context.Background(), "USD", "EUR", start, end); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history.rates) != 2 {
+		t.Fatalf("expected 2 persisted days, got %d", len(history.rates))
+	}
+	if external.rangeCalls != 1 {
+		t.Fatalf("expected a single upstream range call, got %d", external.rangeCalls)
+	}
+}
+
+func TestGetLatestRate_NotServedFromHistoricalTable(t *testing.T) {
+	date := truncateToDay(time.Now().AddDate(0, 0, -3))
+	external := &fakeExternalRepo{
+		rates:           map[string]float64{"USD": 1, "EUR": 0.9},
+		historicalRates: map[string]float64{"USD": 1, "EUR": 0.7},
+	}
+	memoryCache := cache.NewInMemoryCache(time.Hour, cache.Limits{})
+	defer memoryCache.Close()
+	cacheRepo := inmemory.NewInMemoryRepository(memoryCache, testRegistry, inmemory.TTLPolicy{Latest: time.Hour})
+	uc := NewExchangeRateUseCase(external, cacheRepo, nil, testRegistry, Options{MaxHistoricalDays: 90})
+	amount, _ := domain_exchange.ParseDecimal("1")
+
+	if _, err := uc.ConvertAmount(context.Background(), domain_exchange.ConversionRequest{From: "USD", To: "EUR", Amount: amount, FromDate: date, ToDate: date}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rate, err := uc.GetLatestRate(context.Background(), "USD", "EUR")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rate.Rate != 0.9 {
+		t.Fatalf("expected latest rate 0.9, got %v (historical table served as latest)", rate.Rate)
+	}
+	if external.latestCalls != 1 {
+		t.Fatalf("expected one upstream latest call, got %d", external.latestCalls)
+	}
+}
+
+func TestGetHistoricalRate_CachedUnderItsOwnDate(t *testing.T) {
+	first := truncateToDay(time.Now().AddDate(0, 0, -5))
+	second := first.AddDate(0, 0, 1)
+	external := &fakeExternalRepo{rates: map[string]float64{"USD": 1, "EUR": 0.9}}
+	memoryCache := cache.NewInMemoryCache(time.Hour, cache.Limits{})
+	defer memoryCache.Close()
+	cacheRepo := inmemory.NewInMemoryRepository(memoryCache, testRegistry, inmemory.TTLPolicy{Latest: time.Hour})
+	uc := NewExchangeRateUseCase(external, cacheRepo, nil, testRegistry, Options{MaxHistoricalDays: 90})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := uc.(*exchangeRateUseCase).getHistoricalRate(ctx, "USD", "EUR", first); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if external.dateCalls != 1 {
+		t.Fatalf("expected the second lookup to hit the cache, got %d upstream calls", external.dateCalls)
+	}
+
+	if _, err := uc.(*exchangeRateUseCase).getHistoricalRate(ctx, "USD", "EUR", second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if external.dateCalls != 2 {
+		t.Fatalf("expected a different date to miss the cache, got %d upstream calls", external.dateCalls)
+	}
+
+	if _, err := cacheRepo.GetCachedRate(ctx, "USD", "EUR", time.Now()); err == nil {
+		t.Fatal("expected no table cached under today's date")
+	}
+}
+
+func (f *fakeExternalRepo) calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.latestCalls
+}
+
+func (f *fakeExternalRepo) failLatest(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latestErr = err
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestGetLatestRates_ServesStaleWhileRevalidating(t *testing.T) {
+	external := &fakeExternalRepo{rates: map[string]float64{"USD": 1, "EUR": 0.9}}
+	cacheRepo := newFakeCacheRepo()
+	uc := NewExchangeRateUseCase(external, cacheRepo, nil, testRegistry, Options{MaxHistoricalDays: 90, MaxStaleAge: time.Hour})
+	ctx := context.Background()
+
+	if _, err := uc.GetLatestRates(ctx, "USD"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cacheRepo.expireAll()
+	external.failLatest(errors.New("upstream down"))
+	for i := 0; i < 5; i++ {
+		table, err := uc.GetLatestRates(ctx, "USD")
+		if err != nil {
+			t.Fatalf("expected stale table instead of error: %v", err)
+		}
+		if !table.Stale || table.ConversionRates["EUR"] != 0.9 {
+			t.Fatalf("expected stale EUR rate, got %+v", table)
+		}
+	}
+
+	waitFor(t, func() bool { return external.calls() >= 2 })
+	external.failLatest(nil)
+	waitFor(t, func() bool {
+		table, err := uc.GetLatestRates(ctx, "USD")
+		return err == nil && !table.Stale
+	})
+}
+
+// rememberingRepo hands over an old table, like a provider serving what it
+// remembers during an outage.
+type rememberingRepo struct {
+	fakeExternalRepo
+	fetchedAt time.Time
+}
+
+func (r *rememberingRepo) GetLatestRate(ctx context.Context, fromCurrency string) (*domain_exchange.ExchangeRate, error) {
+	return &domain_exchange.ExchangeRate{
+		Result:          "success",
+		BaseCode:        fromCurrency,
+		ConversionRates: map[string]float64{"USD": 1, "EUR": 0.9},
+		FetchedAt:       r.fetchedAt,
+		Date:            domain_exchange.StartOfDay(r.fetchedAt),
+		Provider:        "fake",
+	}, nil
+}
+
+func TestGetLatestRates_MarksOldUpstreamTablesStale(t *testing.T) {
+	external := &rememberingRepo{fetchedAt: time.Now().Add(-26 * time.Hour)}
+	cacheRepo := newFakeCacheRepo()
+	uc := NewExchangeRateUseCase(external, cacheRepo, nil, testRegistry, Options{MaxHistoricalDays: 90, LatestTTL: time.Hour})
+
+	table, err := uc.GetLatestRates(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !table.Stale {
+		t.Fatal("expected a table older than the latest TTL to be reported stale")
+	}
+	if len(cacheRepo.rates) != 0 {
+		t.Fatalf("expected the old table not to be cached, got %v", cacheRepo.rates)
+	}
+}
+
+func TestRefreshRates_StaleTablesCountAsFailures(t *testing.T) {
+	external := &rememberingRepo{fetchedAt: time.Now().Add(-26 * time.Hour)}
+	uc := NewExchangeRateUseCase(external, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90, LatestTTL: time.Hour})
+	refreshed := metrics.LastSuccessfulRefresh.WithLabelValues("GBP")
+	refreshed.Set(0)
+
+	result, err := uc.RefreshRates(context.Background())
+	if !errors.Is(err, domain_exchange.ErrUpstreamFailure) {
+		t.Fatalf("expected the stale bases to fail the refresh, got %v", err)
+	}
+	if len(result.Succeeded) != 0 || len(result.Failed) != len(testRegistry.List()) {
+		t.Fatalf("expected every base to be reported failed, got %+v", result)
+	}
+	if got := testutil.ToFloat64(refreshed); got != 0 {
+		t.Fatalf("expected no successful refresh to be recorded, got %v", got)
+	}
+}
+
+func TestGetLatestRates_SingleBackgroundRefresh(t *testing.T) {
+	external := &fakeExternalRepo{rates: map[string]float64{"USD": 1, "EUR": 0.9}}
+	cacheRepo := newFakeCacheRepo()
+	uc := NewExchangeRateUseCase(external, cacheRepo, nil, testRegistry, Options{MaxHistoricalDays: 90, MaxStaleAge: time.Hour}).(*exchangeRateUseCase)
+	ctx := context.Background()
+
+	if _, err := uc.GetLatestRates(ctx, "USD"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cacheRepo.expireAll()
+
+	// Hold the refresh slot so every stale read finds a refresh in flight.
+	uc.refreshing["USD"] = true
+	for i := 0; i < 5; i++ {
+		if _, err := uc.GetLatestRates(ctx, "USD"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if calls := external.calls(); calls != 1 {
+		t.Fatalf("expected no extra upstream calls while a refresh is pending, got %d", calls)
+	}
+}
+
+func TestGetLatestRates_FailsBeyondMaxStaleAge(t *testing.T) {
+	external := &fakeExternalRepo{rates: map[string]float64{"USD": 1, "EUR": 0.9}}
+	cacheRepo := newFakeCacheRepo()
+	uc := NewExchangeRateUseCase(external, cacheRepo, nil, testRegistry, Options{MaxHistoricalDays: 90, MaxStaleAge: 20 * time.Millisecond})
+	ctx := context.Background()
+
+	if _, err := uc.GetLatestRates(ctx, "USD"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cacheRepo.expireAll()
+	external.failLatest(errors.New("upstream down"))
+	time.Sleep(40 * time.Millisecond)
+
+	if _, err := uc.GetLatestRates(ctx, "USD"); err == nil {
+		t.Fatal("expected error once the last known table is older than the hard max age")
+	}
+}
+
+func TestGetLatestRate_CoalescesConcurrentMisses(t *testing.T) {
+	gate := make(chan struct{})
+	external := &fakeExternalRepo{
+		rates:      map[string]float64{"USD": 1, "EUR": 0.9},
+		latestGate: gate,
+	}
+	uc := NewExchangeRateUseCase(external, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90})
+	coalesced := metrics.CoalescedRequests.WithLabelValues("latest")
+	before := testutil.ToFloat64(coalesced)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := uc.GetLatestRate(context.Background(), "USD", "EUR"); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	// Release the upstream only once every other caller joined the fetch.
+	waitFor(t, func() bool { return testutil.ToFloat64(coalesced)-before == callers-1 })
+	close(gate)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls := external.calls(); calls != 1 {
+		t.Fatalf("expected a single upstream fetch, got %d", calls)
+	}
+}
+
+func TestGetLatestRate_CoalescedFetchOutlivesFirstCaller(t *testing.T) {
+	gate := make(chan struct{})
+	external := &fakeExternalRepo{
+		rates:      map[string]float64{"USD": 1, "EUR": 0.9},
+		latestGate: gate,
+	}
+	uc := NewExchangeRateUseCase(external, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90})
+	coalesced := metrics.CoalescedRequests.WithLabelValues("latest")
+	before := testutil.ToFloat64(coalesced)
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	firstDone := make(chan struct{})
+	go func() {
+		defer close(firstDone)
+		uc.GetLatestRate(firstCtx, "USD", "EUR")
+	}()
+	waitFor(t, func() bool { return external.calls() == 1 })
+
+	followerErr := make(chan error, 1)
+	go func() {
+		_, err := uc.GetLatestRate(context.Background(), "USD", "EUR")
+		followerErr <- err
+	}()
+	waitFor(t, func() bool { return testutil.ToFloat64(coalesced)-before == 1 })
+
+	// The first client leaving must not cancel the fetch the follower waits on.
+	cancelFirst()
+	close(gate)
+	if err := <-followerErr; err != nil {
+		t.Fatalf("unexpected error for the follower: %v", err)
+	}
+	<-firstDone
+}
+
+func TestRefreshRates_ReportsSucceededAndFailedBases(t *testing.T) {
+	external := &fakeExternalRepo{
+		rates:        map[string]float64{"USD": 1, "EUR": 0.9},
+		failingBases: map[string]bool{"JPY": true, "BTC": true},
+	}
+	cacheRepo := newFakeCacheRepo()
+	uc := NewExchangeRateUseCase(external, cacheRepo, nil, testRegistry, Options{MaxHistoricalDays: 90, RefreshConcurrency: 2})
+
+	result, err := uc.RefreshRates(context.Background())
+	if err == nil {
+		t.Fatal("expected an error listing the failed bases")
+	}
+	if want := []string{"EUR", "GBP", "INR", "USD"}; !slices.Equal(result.Succeeded, want) {
+		t.Fatalf("expected %v to succeed, got %v", want, result.Succeeded)
+	}
+	if len(result.Failed) != 2 || result.Failed[0].Currency != "BTC" || result.Failed[1].Currency != "JPY" {
+		t.Fatalf("expected BTC and JPY to fail, got %+v", result.Failed)
+	}
+	if external.maxInFlight > 2 {
+		t.Fatalf("expected at most 2 concurrent fetches, got %d", external.maxInFlight)
+	}
+	if _, err := cacheRepo.GetCachedRate(context.Background(), "EUR", "", time.Now()); err != nil {
+		t.Fatal("expected refreshed table to be cached before RefreshRates returns")
+	}
+}
+
+func TestRefreshRates_StopsAtDeadline(t *testing.T) {
+	gate := make(chan struct{})
+	defer close(gate)
+	external := &fakeExternalRepo{rates: map[string]float64{"USD": 1}, latestGate: gate}
+	uc := NewExchangeRateUseCase(external, newFakeCacheRepo(), nil, testRegistry, Options{
+		MaxHistoricalDays:  90,
+		RefreshConcurrency: 1,
+		RefreshTimeout:     20 * time.Millisecond,
+	})
+
+	result, err := uc.RefreshRates(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+	if len(result.Succeeded) != 0 || len(result.Failed) != len(testRegistry.List()) {
+		t.Fatalf("expected every base to be reported failed, got %+v", result)
+	}
+}
+
+func TestConvertAmount_ClassifiesErrors(t *testing.T) {
+	external := &fakeExternalRepo{rates: map[string]float64{"EUR": 0.9}}
+	uc := NewExchangeRateUseCase(external, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90})
+	amount, _ := domain_exchange.ParseDecimal("1")
+	ctx := context.Background()
+
+	tests := []struct {
+		request domain_exchange.ConversionRequest
+		kind    error
+	}{
+		{domain_exchange.ConversionRequest{From: "USD", To: "XYZ", Amount: amount}, domain_exchange.ErrUnsupportedCurrency},
+		{domain_exchange.ConversionRequest{From: "USD", To: "EUR", Amount: amount, FromDate: time.Now().AddDate(0, 0, 2)}, domain_exchange.ErrDateOutOfRange},
+		{domain_exchange.ConversionRequest{From: "USD", To: "GBP", Amount: amount}, domain_exchange.ErrRateUnavailable},
+		{domain_exchange.ConversionRequest{From: "USD", To: "EUR"}, domain_exchange.ErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		if _, err := uc.ConvertAmount(ctx, tt.request); !errors.Is(err, tt.kind) {
+			t.Errorf("ConvertAmount(%+v) = %v, want %v", tt.request, err, tt.kind)
+		}
+	}
+
+	external.failLatest(errors.New("upstream down"))
+	if _, err := uc.ConvertAmount(ctx, domain_exchange.ConversionRequest{From: "EUR", To: "USD", Amount: amount}); !errors.Is(err, domain_exchange.ErrUpstreamFailure) {
+		t.Errorf("expected an upstream failure, got %v", err)
+	}
+}
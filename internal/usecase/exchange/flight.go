@@ -0,0 +1,71 @@
+package exchange
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+	"exchange-rate-service/pkg/logger"
+	"exchange-rate-service/pkg/metrics"
+)
+
+// flightTimeout bounds a shared fetch. It runs detached from the caller that
+// started it, so one client going away does not fail every waiter.
+const flightTimeout = 30 * time.Second
+
+type flightCall struct {
+	done chan struct{}
+	rate *domain_exchange.ExchangeRate
+	err  error
+}
+
+// flightGroup de-duplicates concurrent upstream fetches: callers asking for
+// a key that is already being fetched wait for and share that result.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// do returns the result of fetch for key, running it at most once at a time.
+// Every caller, including the one that started the fetch, stops waiting when
+// its own ctx is done; the fetch itself carries on for the others.
+func (g *flightGroup) do(ctx context.Context, kind, key string, fetch func(context.Context) (*domain_exchange.ExchangeRate, error)) (*domain_exchange.ExchangeRate, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	call, exists := g.calls[key]
+	if exists {
+		metrics.CoalescedRequests.WithLabelValues(kind).Inc()
+	} else {
+		call = &flightCall{done: make(chan struct{})}
+		g.calls[key] = call
+		go g.run(context.WithoutCancel(ctx), key, call, fetch)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.rate, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, call *flightCall, fetch func(context.Context) (*domain_exchange.ExchangeRate, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("Shared fetch of %s panicked: %v", key, r)
+			call.rate, call.err = nil, domain_exchange.Errorf(domain_exchange.ErrUpstreamFailure, "shared fetch of %s failed", key)
+		}
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(call.done)
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, flightTimeout)
+	defer cancel()
+	call.rate, call.err = fetch(ctx)
+}
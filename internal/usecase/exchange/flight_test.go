@@ -0,0 +1,27 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+func TestFlightGroup_PanickingFetchReleasesWaiters(t *testing.T) {
+	var group flightGroup
+
+	_, err := group.do(context.Background(), "latest", "latest:USD", func(context.Context) (*domain_exchange.ExchangeRate, error) {
+		panic("boom")
+	})
+	if !errors.Is(err, domain_exchange.ErrUpstreamFailure) {
+		t.Fatalf("expected ErrUpstreamFailure, got %v", err)
+	}
+
+	rate, err := group.do(context.Background(), "latest", "latest:USD", func(context.Context) (*domain_exchange.ExchangeRate, error) {
+		return &domain_exchange.ExchangeRate{BaseCode: "USD"}, nil
+	})
+	if err != nil || rate.BaseCode != "USD" {
+		t.Fatalf("expected the key to be free for a new fetch, got %v, %v", rate, err)
+	}
+}
@@ -0,0 +1,48 @@
+package exchange
+
+import (
+	"context"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+	"exchange-rate-service/pkg/logger"
+)
+
+// cachedHistoricalTable looks a day up in the cache and then in the
+// persistent history store, warming the cache on a history hit.
+func (s *exchangeRateUseCase) cachedHistoricalTable(ctx context.Context, from, to string, date time.Time) *domain_exchange.ExchangeRate {
+	if cachedRate, err := s.cacheRepo.GetCachedRate(ctx, from, to, date); err == nil && cachedRate != nil {
+		return cachedRate
+	}
+	if s.historyRepo == nil {
+		return nil
+	}
+
+	storedRate, err := s.historyRepo.GetCachedRate(ctx, from, to, date)
+	if err != nil || storedRate == nil {
+		return nil
+	}
+	logger.Infof("History hit for %s on %s", from, date.Format(dayLayout))
+	if err := s.cacheRepo.StoreRate(ctx, storedRate); err != nil {
+		logger.Errorf("Failed to cache historical rate: %v", err)
+	}
+	return storedRate
+}
+
+// storeHistoricalTable caches a fetched day and, once the day is closed,
+// persists it so it never has to be fetched upstream again.
+func (s *exchangeRateUseCase) storeHistoricalTable(ctx context.Context, rate *domain_exchange.ExchangeRate) {
+	if err := s.cacheRepo.StoreRate(ctx, rate); err != nil {
+		logger.Errorf("Failed to cache historical rate: %v", err)
+	}
+	if s.historyRepo == nil || !isClosedDay(rate.Date) {
+		return
+	}
+	if err := s.historyRepo.StoreRate(ctx, rate); err != nil {
+		logger.Errorf("Failed to persist historical rate: %v", err)
+	}
+}
+
+func isClosedDay(date time.Time) bool {
+	return !date.IsZero() && date.Before(truncateToDay(time.Now()))
+}
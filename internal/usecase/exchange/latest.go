@@ -0,0 +1,142 @@
+package exchange
+
+import (
+	"context"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+	"exchange-rate-service/pkg/logger"
+)
+
+// backgroundRefreshTimeout bounds a revalidation that no request waits for.
+const backgroundRefreshTimeout = 30 * time.Second
+
+// latestTable returns the latest table for from, derived from the pivot
+// table when triangulation applies to from.
+func (s *exchangeRateUseCase) latestTable(ctx context.Context, from string) (*domain_exchange.ExchangeRate, error) {
+	base := s.tableBase(from)
+	if base == from {
+		return s.baseLatestTable(ctx, from)
+	}
+
+	pivotTable, err := s.baseLatestTable(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+	if derived := deriveTable(pivotTable, from); derived != nil {
+		return derived, nil
+	}
+	logger.Warnf("Pivot table %s has no quote for %s, fetching its own table", base, from)
+	return s.baseLatestTable(ctx, from)
+}
+
+// baseLatestTable serves the cached latest table for from. Once the cache
+// entry has expired the last known table keeps being served, marked stale,
+// while a single background refresh replaces it; callers only block on the
+// upstream when there is no table younger than maxStaleAge.
+func (s *exchangeRateUseCase) baseLatestTable(ctx context.Context, from string) (*domain_exchange.ExchangeRate, error) {
+	if cachedRate, err := s.cacheRepo.GetCachedRate(ctx, from, "", time.Now()); err == nil && cachedRate != nil {
+		logger.Infof("Cache hit for latest base table %s", from)
+		s.remember(cachedRate)
+		return cachedRate, nil
+	}
+
+	if stale := s.staleTable(from); stale != nil {
+		logger.Infof("Serving stale latest table %s aged %v while revalidating", from, stale.Age().Round(time.Second))
+		s.revalidate(from)
+		return stale, nil
+	}
+
+	return s.fetchLatestTable(ctx, from)
+}
+
+// fetchLatestTable asks the upstream for a fresh table and caches it.
+// Concurrent callers for the same base share a single upstream request.
+// Providers may hand over a remembered table during an outage; such tables
+// are marked stale and are not cached as today's latest entry.
+func (s *exchangeRateUseCase) fetchLatestTable(ctx context.Context, from string) (*domain_exchange.ExchangeRate, error) {
+	return s.flights.do(ctx, "latest", "latest:"+from, func(ctx context.Context) (*domain_exchange.ExchangeRate, error) {
+		rate, err := s.externalRepo.GetLatestRate(ctx, from)
+		if err != nil {
+			return nil, domain_exchange.Errorf(domain_exchange.ErrUpstreamFailure, "failed to fetch latest rate: %w", err)
+		}
+		if s.maxStaleAge > 0 && rate.Age() > s.maxStaleAge {
+			return nil, domain_exchange.Errorf(domain_exchange.ErrUpstreamFailure, "latest rates for %s are %v old, beyond the %v limit",
+				from, rate.Age().Round(time.Second), s.maxStaleAge)
+		}
+		if ttl := s.latestTTLFor(from); ttl > 0 && rate.Age() > ttl && !rate.Stale {
+			marked := *rate
+			marked.Stale = true
+			rate = &marked
+		}
+		if rate.Stale || rate.Date.Before(domain_exchange.StartOfDay(time.Now())) {
+			logger.Warnf("Not caching latest table %s fetched at %s", from, rate.FetchedAt.Format(time.RFC3339))
+		} else if err := s.cacheRepo.StoreRate(ctx, rate); err != nil {
+			logger.Errorf("Failed to cache rate: %v", err)
+		}
+		s.remember(rate)
+		return rate, nil
+	})
+}
+
+// revalidate starts a background refresh for from unless one is running.
+func (s *exchangeRateUseCase) revalidate(from string) {
+	s.latestMu.Lock()
+	if s.refreshing[from] {
+		s.latestMu.Unlock()
+		return
+	}
+	s.refreshing[from] = true
+	s.latestMu.Unlock()
+
+	go func() {
+		defer func() {
+			s.latestMu.Lock()
+			delete(s.refreshing, from)
+			s.latestMu.Unlock()
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), backgroundRefreshTimeout)
+		defer cancel()
+		if _, err := s.fetchLatestTable(ctx, from); err != nil {
+			logger.Errorf("Background refresh of %s failed: %v", from, err)
+		}
+	}()
+}
+
+func (s *exchangeRateUseCase) remember(rate *domain_exchange.ExchangeRate) {
+	if s.maxStaleAge <= 0 {
+		return
+	}
+	s.latestMu.Lock()
+	defer s.latestMu.Unlock()
+	if known, exists := s.lastKnown[rate.BaseCode]; !exists || !known.FetchedAt.After(rate.FetchedAt) {
+		s.lastKnown[rate.BaseCode] = rate
+	}
+}
+
+// staleTable returns a stale-marked copy of the last known table for from,
+// or nil when there is none younger than maxStaleAge.
+func (s *exchangeRateUseCase) staleTable(from string) *domain_exchange.ExchangeRate {
+	if s.maxStaleAge <= 0 {
+		return nil
+	}
+	s.latestMu.Lock()
+	defer s.latestMu.Unlock()
+
+	known, exists := s.lastKnown[from]
+	if !exists || known.Age() > s.maxStaleAge {
+		return nil
+	}
+	stale := *known
+	stale.Stale = true
+	return &stale
+}
+
+// latestTTLFor mirrors the cache's TTL policy for latest tables.
+func (s *exchangeRateUseCase) latestTTLFor(base string) time.Duration {
+	if currency, exists := s.registry.Get(base); exists && currency.Type == domain_exchange.CurrencyTypeCrypto && s.cryptoLatestTTL > 0 {
+		return s.cryptoLatestTTL
+	}
+	return s.latestTTL
+}
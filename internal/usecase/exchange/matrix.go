@@ -0,0 +1,128 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+const (
+	// maxMatrixCurrencies bounds the N×N work of a single matrix request.
+	maxMatrixCurrencies = 20
+	// inverseToleranceBps is how far rate(a→b)·rate(b→a) may stray from one
+	// before the pair is reported as inconsistent.
+	inverseToleranceBps = 1.0
+)
+
+// GetRateMatrix builds the cross rates between every pair of currencies,
+// resolving each base table once.
+func (s *exchangeRateUseCase) GetRateMatrix(ctx context.Context, currencies []string, date time.Time, checkInverse bool) (*domain_exchange.RateMatrix, error) {
+	codes := make([]string, 0, len(currencies))
+	seen := make(map[string]bool, len(currencies))
+	for _, code := range currencies {
+		if seen[code] {
+			continue
+		}
+		if _, exists := s.registry.Get(code); !exists {
+			return nil, domain_exchange.Errorf(domain_exchange.ErrUnsupportedCurrency, "currency %s is not supported", code)
+		}
+		seen[code] = true
+		codes = append(codes, code)
+	}
+	if len(codes) < 2 {
+		return nil, domain_exchange.Errorf(domain_exchange.ErrInvalidRequest, "at least 2 distinct currencies are required")
+	}
+	if len(codes) > maxMatrixCurrencies {
+		return nil, domain_exchange.Errorf(domain_exchange.ErrInvalidRequest, "at most %d currencies are allowed", maxMatrixCurrencies)
+	}
+	if !date.IsZero() {
+		if err := s.ValidateDate(date, s.maxHistoricalDays); err != nil {
+			return nil, err
+		}
+	}
+
+	matrix := &domain_exchange.RateMatrix{
+		Currencies: codes,
+		Date:       date,
+		Rates:      make(map[string]map[string]float64, len(codes)),
+	}
+	tables := make(tableMemo)
+	for _, from := range codes {
+		row := make(map[string]float64, len(codes))
+		for _, to := range codes {
+			rate, err := s.matrixRate(ctx, tables, from, to, date)
+			if errors.Is(err, domain_exchange.ErrRateUnavailable) {
+				matrix.Missing = append(matrix.Missing, from+"/"+to)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			row[to] = rate
+		}
+		matrix.Rates[from] = row
+	}
+
+	if checkInverse {
+		s.checkInverses(ctx, tables, matrix)
+	}
+	return matrix, nil
+}
+
+func (s *exchangeRateUseCase) matrixRate(ctx context.Context, tables tableMemo, from, to string, date time.Time) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	table, err := s.memoTable(ctx, tables, from, to, date)
+	if err != nil {
+		return 0, err
+	}
+	if rate, exists := crossRate(table, from, to); exists {
+		return rate, nil
+	}
+	// Cross-type pairs live in other tables or go through the bridge.
+	q, err := s.resolveRate(ctx, from, to, date)
+	return q.rate, err
+}
+
+// checkInverses lists the pairs whose rate and inverse rate disagree by more
+// than inverseToleranceBps. Rows derived from the pivot table agree with each
+// other by construction, so their side of a pair is read from the currency's
+// own table instead; pairs without such a quote are listed as unchecked.
+func (s *exchangeRateUseCase) checkInverses(ctx context.Context, tables tableMemo, matrix *domain_exchange.RateMatrix) {
+	for i, from := range matrix.Currencies {
+		for _, to := range matrix.Currencies[i+1:] {
+			_, rateExists := matrix.Rates[from][to]
+			_, inverseExists := matrix.Rates[to][from]
+			if !rateExists || !inverseExists {
+				continue
+			}
+			rate, rateChecked := s.checkedRate(ctx, tables, matrix, from, to)
+			inverse, inverseChecked := s.checkedRate(ctx, tables, matrix, to, from)
+			if !rateChecked || !inverseChecked {
+				matrix.Unchecked = append(matrix.Unchecked, from+"/"+to)
+				continue
+			}
+			if bps := math.Abs(rate*inverse-1) * 10000; bps > inverseToleranceBps {
+				matrix.Inconsistencies = append(matrix.Inconsistencies, domain_exchange.RateInconsistency{From: from, To: to, DeviationBps: bps})
+			}
+		}
+	}
+}
+
+// checkedRate is the from→to rate held against its inverse: the matrix rate,
+// or the quote of from's own table when from's row is derived from the pivot.
+func (s *exchangeRateUseCase) checkedRate(ctx context.Context, tables tableMemo, matrix *domain_exchange.RateMatrix, from, to string) (float64, bool) {
+	if s.tableBase(from) == from {
+		return matrix.Rates[from][to], true
+	}
+	table, err := s.memoTableOf(ctx, tables, from, to, matrix.Date)
+	if err != nil {
+		return 0, false
+	}
+	rate, exists := table.ConversionRates[to]
+	return rate, exists && rate != 0
+}
@@ -0,0 +1,125 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+func TestGetRateMatrix_ResolvesPivotTableOnce(t *testing.T) {
+	external := &fakeExternalRepo{rates: usdTable.ConversionRates}
+	uc := newPivotUseCase(external)
+
+	matrix, err := uc.GetRateMatrix(context.Background(), []string{"USD", "EUR", "GBP", "EUR"}, time.Time{}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(matrix.Currencies, []string{"USD", "EUR", "GBP"}) {
+		t.Fatalf("expected duplicates dropped, got %v", matrix.Currencies)
+	}
+	if matrix.Rates["EUR"]["GBP"] != 0.625 || matrix.Rates["GBP"]["GBP"] != 1 || matrix.Rates["GBP"]["USD"] != 2 {
+		t.Fatalf("unexpected rates %v", matrix.Rates)
+	}
+	if len(matrix.Missing) != 0 {
+		t.Fatalf("expected a complete matrix, got missing %v", matrix.Missing)
+	}
+	if external.calls() != 1 {
+		t.Fatalf("expected a single pivot table fetch, got %d", external.calls())
+	}
+}
+
+func TestGetRateMatrix_ReportsMissingAndInconsistentPairs(t *testing.T) {
+	tables := tablesRepo{
+		"USD": {"USD": 1, "INR": 80},
+		"INR": {"INR": 1, "USD": 0.013},
+		"BTC": {"BTC": 1, "USD": 50000},
+		"JPY": {"JPY": 1},
+	}
+	uc := NewExchangeRateUseCase(tables, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90})
+
+	matrix, err := uc.GetRateMatrix(context.Background(), []string{"USD", "INR", "BTC", "JPY"}, time.Time{}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if matrix.Rates["BTC"]["INR"] != 4000000 {
+		t.Fatalf("expected BTC/INR through USD, got %v", matrix.Rates["BTC"]["INR"])
+	}
+	if !slices.Contains(matrix.Missing, "JPY/USD") || !slices.Contains(matrix.Missing, "USD/JPY") {
+		t.Fatalf("expected JPY pairs to be missing, got %v", matrix.Missing)
+	}
+	// The INR table's USD quote also skews INR/BTC, which is bridged through it.
+	if len(matrix.Inconsistencies) != 2 {
+		t.Fatalf("expected two inconsistent pairs, got %v", matrix.Inconsistencies)
+	}
+	if got := matrix.Inconsistencies[0]; got.From != "USD" || got.To != "INR" || math.Abs(got.DeviationBps-400) > 1e-6 {
+		t.Fatalf("unexpected inconsistency %+v", got)
+	}
+}
+
+func TestGetRateMatrix_ReturnsUpstreamFailures(t *testing.T) {
+	tables := tablesRepo{"USD": {"USD": 1, "INR": 80}}
+	uc := NewExchangeRateUseCase(tables, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90})
+
+	_, err := uc.GetRateMatrix(context.Background(), []string{"USD", "INR"}, time.Time{}, false)
+	if !errors.Is(err, domain_exchange.ErrUpstreamFailure) {
+		t.Fatalf("expected ErrUpstreamFailure, got %v", err)
+	}
+}
+
+func TestGetRateMatrix_RejectsInvalidInput(t *testing.T) {
+	uc := newPivotUseCase(&fakeExternalRepo{rates: usdTable.ConversionRates})
+	ctx := context.Background()
+
+	if _, err := uc.GetRateMatrix(ctx, []string{"USD"}, time.Time{}, false); err == nil {
+		t.Fatal("expected a single currency to be rejected")
+	}
+	if _, err := uc.GetRateMatrix(ctx, []string{"USD", "XYZ"}, time.Time{}, false); err == nil {
+		t.Fatal("expected an unsupported currency to be rejected")
+	}
+	if _, err := uc.GetRateMatrix(ctx, []string{"USD", "EUR"}, time.Now().AddDate(0, 0, 1), false); err == nil {
+		t.Fatal("expected a future date to be rejected")
+	}
+}
+
+func TestGetRateMatrix_ChecksDerivedRowsAgainstOwnTables(t *testing.T) {
+	tables := tablesRepo{
+		"USD": {"USD": 1, "EUR": 0.8, "GBP": 0.5},
+		"EUR": {"EUR": 1, "USD": 1.25, "GBP": 0.7},
+		"GBP": {"GBP": 1, "USD": 2},
+	}
+	newUseCase := func() domain_exchange.ExchangeRateUsercase {
+		return NewExchangeRateUseCase(tables, newFakeCacheRepo(), nil, testRegistry, Options{
+			MaxHistoricalDays: 90,
+			PivotCurrency:     "USD",
+		})
+	}
+
+	matrix, err := newUseCase().GetRateMatrix(context.Background(), []string{"USD", "EUR", "GBP"}, time.Time{}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if matrix.Rates["EUR"]["GBP"] != 0.625 {
+		t.Fatalf("expected rates derived from the pivot, got %v", matrix.Rates)
+	}
+	// EUR's own GBP quote is off by 12%; GBP's own table has no EUR quote.
+	if !slices.Equal(matrix.Unchecked, []string{"EUR/GBP"}) {
+		t.Fatalf("expected EUR/GBP to be unchecked, got %v", matrix.Unchecked)
+	}
+	if len(matrix.Inconsistencies) != 0 {
+		t.Fatalf("expected USD pairs to agree with the own tables, got %v", matrix.Inconsistencies)
+	}
+
+	tables["GBP"]["EUR"] = 1.6
+	matrix, err = newUseCase().GetRateMatrix(context.Background(), []string{"USD", "EUR", "GBP"}, time.Time{}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(matrix.Inconsistencies) != 1 || matrix.Inconsistencies[0].From != "EUR" || math.Abs(matrix.Inconsistencies[0].DeviationBps-1200) > 1e-6 {
+		t.Fatalf("expected EUR/GBP to be inconsistent by 1200 bps, got %v", matrix.Inconsistencies)
+	}
+}
@@ -0,0 +1,181 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+	"exchange-rate-service/pkg/logger"
+)
+
+// bridgeCurrency joins the fiat and crypto tables: every provider quotes it,
+// so a pair no single table covers is converted through it.
+const bridgeCurrency = "USD"
+
+// quote is a resolved rate together with what it was read from.
+type quote struct {
+	rate float64
+	path domain_exchange.ConversionPath
+	// date is the effective day of the oldest table used.
+	date      time.Time
+	fetchedAt time.Time
+	providers []string
+	stale     bool
+}
+
+// joinProviders lists the providers behind the quotes, each once.
+func joinProviders(quotes ...quote) string {
+	var providers []string
+	for _, q := range quotes {
+		for _, provider := range q.providers {
+			if !slices.Contains(providers, provider) {
+				providers = append(providers, provider)
+			}
+		}
+	}
+	return strings.Join(providers, ",")
+}
+
+// add folds the table a leg was read from into the quote.
+func (q *quote) add(rate float64, table *domain_exchange.ExchangeRate) {
+	q.rate *= rate
+	q.stale = q.stale || table.Stale
+	if q.date.IsZero() || (!table.Date.IsZero() && table.Date.Before(q.date)) {
+		q.date = table.Date
+	}
+	if q.fetchedAt.IsZero() || (!table.FetchedAt.IsZero() && table.FetchedAt.Before(q.fetchedAt)) {
+		q.fetchedAt = table.FetchedAt
+	}
+	if table.Provider != "" && !slices.Contains(q.providers, table.Provider) {
+		q.providers = append(q.providers, table.Provider)
+	}
+}
+
+// resolveRate returns the from→to rate on date (the latest when date is
+// zero) together with the currencies the conversion went through. Pairs no
+// table quotes directly are bridged through bridgeCurrency; other failures,
+// such as an unavailable upstream, are returned as they are.
+func (s *exchangeRateUseCase) resolveRate(ctx context.Context, from, to string, date time.Time) (quote, error) {
+	direct := domain_exchange.ConversionPath{from, to}
+	q, err := s.rateAlongPath(ctx, direct, date)
+	if err == nil {
+		return q, nil
+	}
+	if !errors.Is(err, domain_exchange.ErrRateUnavailable) {
+		return quote{}, err
+	}
+	if from == bridgeCurrency || to == bridgeCurrency {
+		return quote{}, err
+	}
+	if _, exists := s.registry.Get(bridgeCurrency); !exists {
+		return quote{}, err
+	}
+
+	bridged := domain_exchange.ConversionPath{from, bridgeCurrency, to}
+	q, bridgeErr := s.rateAlongPath(ctx, bridged, date)
+	if bridgeErr != nil {
+		// A missing bridge leg says no more than the direct error; anything
+		// else, like an outage, is what the caller needs to hear about.
+		if errors.Is(bridgeErr, domain_exchange.ErrRateUnavailable) {
+			return quote{}, err
+		}
+		return quote{}, bridgeErr
+	}
+	logger.Infof("Converted %s to %s via %s", from, to, bridged)
+	return q, nil
+}
+
+// rateAlongPath multiplies the rates of every leg of path.
+func (s *exchangeRateUseCase) rateAlongPath(ctx context.Context, path domain_exchange.ConversionPath, date time.Time) (quote, error) {
+	q := quote{rate: 1, path: path}
+	for i := 0; i+1 < len(path); i++ {
+		legRate, table, err := s.legRate(ctx, path[i], path[i+1], date)
+		if err != nil {
+			return quote{}, err
+		}
+		q.add(legRate, table)
+	}
+	return q, nil
+}
+
+// legRate reads from→to out of from's table. Across currency types the
+// target's table is consulted as well, since e.g. only the crypto provider
+// quotes USD→BTC.
+func (s *exchangeRateUseCase) legRate(ctx context.Context, from, to string, date time.Time) (float64, *domain_exchange.ExchangeRate, error) {
+	rate, table, err := s.rateFromTableOf(ctx, from, from, to, date)
+	if table != nil {
+		return rate, table, nil
+	}
+	if !s.sameType(from, to) {
+		reverse, reverseTable, reverseErr := s.rateFromTableOf(ctx, to, from, to, date)
+		if reverseTable != nil {
+			return reverse, reverseTable, nil
+		}
+		if err == nil {
+			err = reverseErr
+		}
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	if date.IsZero() {
+		return 0, nil, domain_exchange.Errorf(domain_exchange.ErrRateUnavailable, "conversion rate from %s to %s not found", from, to)
+	}
+	return 0, nil, domain_exchange.Errorf(domain_exchange.ErrRateUnavailable, "conversion rate from %s to %s not found for date %s", from, to, date.Format(dayLayout))
+}
+
+// rateFromTableOf looks from→to up in the table answering for owner, which is
+// one of the two currencies. The table is nil when it has no such rate.
+func (s *exchangeRateUseCase) rateFromTableOf(ctx context.Context, owner, from, to string, date time.Time) (float64, *domain_exchange.ExchangeRate, error) {
+	if date.IsZero() {
+		table, err := s.latestTable(ctx, owner)
+		if err != nil {
+			return 0, nil, err
+		}
+		if rate, exists := crossRate(table, from, to); exists {
+			return rate, table, nil
+		}
+		return 0, nil, nil
+	}
+
+	counter := to
+	if owner == to {
+		counter = from
+	}
+	base := s.tableBase(owner)
+	rate, table, err := s.historicalRateFrom(ctx, base, counter, from, to, date)
+	if err != nil || table != nil || base == owner {
+		return rate, table, err
+	}
+	logger.Warnf("Pivot table %s on %s has no quote for %s to %s, fetching the table of %s",
+		base, date.Format(dayLayout), from, to, owner)
+	return s.historicalRateFrom(ctx, owner, counter, from, to, date)
+}
+
+// historicalRateFrom looks from→to up in base's table of date, cached or
+// fetched. The table is nil when it has no such rate.
+func (s *exchangeRateUseCase) historicalRateFrom(ctx context.Context, base, counter, from, to string, date time.Time) (float64, *domain_exchange.ExchangeRate, error) {
+	if cachedRate := s.cachedHistoricalTable(ctx, base, counter, date); cachedRate != nil {
+		if rate, exists := crossRate(cachedRate, from, to); exists {
+			logger.Infof("Cache hit for historical rate %s to %s on %s", from, to, date.Format(dayLayout))
+			return rate, cachedRate, nil
+		}
+	}
+	table, err := s.fetchHistoricalTable(ctx, base, counter, date)
+	if err != nil {
+		return 0, nil, err
+	}
+	if rate, exists := crossRate(table, from, to); exists {
+		return rate, table, nil
+	}
+	return 0, nil, nil
+}
+
+func (s *exchangeRateUseCase) sameType(a, b string) bool {
+	first, firstExists := s.registry.Get(a)
+	second, secondExists := s.registry.Get(b)
+	return firstExists && secondExists && first.Type == second.Type
+}
@@ -0,0 +1,138 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+// tablesRepo serves a distinct table per base, like the fiat and crypto
+// providers that only quote their own currencies.
+type tablesRepo map[string]map[string]float64
+
+func (r tablesRepo) Name() string {
+	return "tables"
+}
+
+func (r tablesRepo) table(base string, date time.Time) (*domain_exchange.ExchangeRate, error) {
+	rates, exists := r[base]
+	if !exists {
+		return nil, fmt.Errorf("no table for %s", base)
+	}
+	return &domain_exchange.ExchangeRate{
+		Result:          "success",
+		BaseCode:        base,
+		ConversionRates: rates,
+		FetchedAt:       time.Now(),
+		Date:            date,
+		Provider:        "tables",
+	}, nil
+}
+
+func (r tablesRepo) GetLatestRate(ctx context.Context, fromCurrency string) (*domain_exchange.ExchangeRate, error) {
+	return r.table(fromCurrency, domain_exchange.StartOfDay(time.Now()))
+}
+
+func (r tablesRepo) GetRateByDate(ctx context.Context, fromCurrency, toCurrency string, date time.Time) (*domain_exchange.ExchangeRate, error) {
+	return r.table(fromCurrency, domain_exchange.StartOfDay(date))
+}
+
+func (r tablesRepo) GetRatesForDateRange(ctx context.Context, fromCurrency, toCurrency string, startDate, endDate time.Time) ([]*domain_exchange.ExchangeRate, error) {
+	return nil, nil
+}
+
+var crossTypeTables = tablesRepo{
+	"USD": {"USD": 1, "INR": 80, "EUR": 0.8},
+	"INR": {"INR": 1, "USD": 0.0125, "EUR": 0.01},
+	"EUR": {"EUR": 1, "USD": 1.25, "INR": 100},
+	"BTC": {"BTC": 1, "USD": 50000},
+}
+
+func TestConvertAmount_CrossTypeThroughBridge(t *testing.T) {
+	uc := NewExchangeRateUseCase(crossTypeTables, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90})
+	amount, _ := domain_exchange.ParseDecimal("0.5")
+
+	conversion, err := uc.ConvertAmount(context.Background(), domain_exchange.ConversionRequest{From: "BTC", To: "INR", Amount: amount})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(conversion.Path, domain_exchange.ConversionPath{"BTC", "USD", "INR"}) {
+		t.Fatalf("expected conversion through USD, got %v", conversion.Path)
+	}
+	if conversion.FromRate.String() != "4000000" || conversion.ConvertedAtFrom.String() != "2000000.00" {
+		t.Fatalf("unexpected conversion: rate %s, amount %s", conversion.FromRate, conversion.ConvertedAtFrom)
+	}
+}
+
+func TestGetLatestRate_ReadsCrossTypeRateFromTargetTable(t *testing.T) {
+	uc := NewExchangeRateUseCase(crossTypeTables, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90})
+
+	rate, err := uc.GetLatestRate(context.Background(), "USD", "BTC")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(rate.Rate-1.0/50000) > 1e-15 {
+		t.Fatalf("expected the inverse of the BTC table quote, got %v", rate.Rate)
+	}
+	if rate.Path.String() != "USD->BTC" {
+		t.Fatalf("expected a direct path, got %s", rate.Path)
+	}
+}
+
+func TestConvertAmount_SamePathForBothDates(t *testing.T) {
+	uc := NewExchangeRateUseCase(crossTypeTables, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90})
+	amount, _ := domain_exchange.ParseDecimal("1")
+	date := time.Now().AddDate(0, 0, -3)
+
+	conversion, err := uc.ConvertAmount(context.Background(), domain_exchange.ConversionRequest{From: "BTC", To: "EUR", Amount: amount, FromDate: date})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if conversion.Path.String() != "BTC->USD->EUR" {
+		t.Fatalf("unexpected path %s", conversion.Path)
+	}
+	if conversion.FromRate.String() != "40000" || conversion.ToRate.String() != "40000" {
+		t.Fatalf("unexpected rates %s and %s", conversion.FromRate, conversion.ToRate)
+	}
+	if !conversion.FromDate.Equal(domain_exchange.StartOfDay(date)) || !conversion.ToDate.Equal(domain_exchange.StartOfDay(time.Now())) {
+		t.Fatalf("unexpected effective dates %v and %v", conversion.FromDate, conversion.ToDate)
+	}
+}
+
+func TestGetLatestRate_UnknownPairNotBridged(t *testing.T) {
+	uc := NewExchangeRateUseCase(crossTypeTables, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90})
+
+	if _, err := uc.GetLatestRate(context.Background(), "BTC", "JPY"); err == nil {
+		t.Fatal("expected an error when no path reaches JPY")
+	}
+}
+
+// outageRepo fails every latest request and records the bases asked for.
+type outageRepo struct {
+	tablesRepo
+	bases []string
+}
+
+func (r *outageRepo) GetLatestRate(ctx context.Context, fromCurrency string) (*domain_exchange.ExchangeRate, error) {
+	r.bases = append(r.bases, fromCurrency)
+	return nil, errors.New("upstream down")
+}
+
+func TestGetLatestRate_OutageNotBridged(t *testing.T) {
+	external := &outageRepo{tablesRepo: crossTypeTables}
+	uc := NewExchangeRateUseCase(external, newFakeCacheRepo(), nil, testRegistry, Options{MaxHistoricalDays: 90})
+
+	_, err := uc.GetLatestRate(context.Background(), "BTC", "INR")
+	if !errors.Is(err, domain_exchange.ErrUpstreamFailure) {
+		t.Fatalf("expected ErrUpstreamFailure, got %v", err)
+	}
+	if slices.Contains(external.bases, bridgeCurrency) {
+		t.Fatalf("expected no bridged lookup during an outage, asked for %v", external.bases)
+	}
+}
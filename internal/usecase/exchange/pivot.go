@@ -0,0 +1,116 @@
+package exchange
+
+import (
+	"context"
+	"math"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+	"exchange-rate-service/pkg/logger"
+	"exchange-rate-service/pkg/metrics"
+)
+
+// tableBase returns the base currency whose table answers conversions from
+// from. With a pivot configured, currencies of the pivot's type are derived
+// from the pivot table instead of fetching a table of their own.
+func (s *exchangeRateUseCase) tableBase(from string) string {
+	if s.pivot == "" || from == s.pivot {
+		return from
+	}
+	pivot, exists := s.registry.Get(s.pivot)
+	if !exists {
+		return from
+	}
+	if currency, exists := s.registry.Get(from); !exists || currency.Type != pivot.Type {
+		return from
+	}
+	return s.pivot
+}
+
+// crossRate reads from→to out of a table of any base: directly when the table
+// is based on from, otherwise as table[to]/table[from].
+func crossRate(table *domain_exchange.ExchangeRate, from, to string) (float64, bool) {
+	quote := func(code string) (float64, bool) {
+		if code == table.BaseCode {
+			return 1, true
+		}
+		rate, exists := table.ConversionRates[code]
+		return rate, exists && rate != 0
+	}
+
+	if table.BaseCode == from {
+		rate, exists := table.ConversionRates[to]
+		return rate, exists
+	}
+	fromRate, fromExists := quote(from)
+	toRate, toExists := quote(to)
+	if !fromExists || !toExists {
+		return 0, false
+	}
+	return toRate / fromRate, true
+}
+
+// deriveTable rebases a pivot table onto from. It returns nil when the pivot
+// table has no quote for from.
+func deriveTable(table *domain_exchange.ExchangeRate, from string) *domain_exchange.ExchangeRate {
+	if table.BaseCode == from {
+		return table
+	}
+	if rate, exists := table.ConversionRates[from]; !exists || rate == 0 {
+		return nil
+	}
+
+	rates := make(map[string]float64, len(table.ConversionRates)+1)
+	if rate, exists := crossRate(table, from, table.BaseCode); exists {
+		rates[table.BaseCode] = rate
+	}
+	for code := range table.ConversionRates {
+		if rate, exists := crossRate(table, from, code); exists {
+			rates[code] = rate
+		}
+	}
+	rates[from] = 1
+
+	derived := *table
+	derived.BaseCode = from
+	derived.ConversionRates = rates
+	derived.Pivot = table.BaseCode
+	return &derived
+}
+
+// compareWithDirect fetches from's own table and reports how far the rates
+// derived through the pivot stray from it.
+func (s *exchangeRateUseCase) compareWithDirect(ctx context.Context, from string) error {
+	pivotTable, err := s.baseLatestTable(ctx, s.pivot)
+	if err != nil {
+		return err
+	}
+	derived := deriveTable(pivotTable, from)
+	if derived == nil {
+		return nil
+	}
+	direct, err := s.externalRepo.GetLatestRate(ctx, from)
+	if err != nil {
+		return err
+	}
+
+	var (
+		worstCode string
+		worstBps  float64
+	)
+	for code, directRate := range direct.ConversionRates {
+		derivedRate, exists := derived.ConversionRates[code]
+		if !exists || directRate == 0 {
+			continue
+		}
+		if bps := math.Abs(derivedRate-directRate) / directRate * 10000; bps > worstBps {
+			worstCode, worstBps = code, bps
+		}
+	}
+
+	metrics.PivotDeviation.WithLabelValues(from).Set(worstBps)
+	if s.pivotMaxDeviationBps > 0 && worstBps > s.pivotMaxDeviationBps {
+		logger.Warnf("Rates for %s derived via %s deviate %.1f bps from direct quotes for %s (%v vs %v)",
+			from, s.pivot, worstBps, worstCode, derived.ConversionRates[worstCode], direct.ConversionRates[worstCode])
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package exchange
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+var usdTable = &domain_exchange.ExchangeRate{
+	BaseCode:        "USD",
+	ConversionRates: map[string]float64{"USD": 1, "EUR": 0.8, "GBP": 0.5, "JPY": 150},
+	Provider:        "fake",
+}
+
+func TestCrossRate(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     float64
+		ok       bool
+	}{
+		{from: "USD", to: "EUR", want: 0.8, ok: true},
+		{from: "EUR", to: "GBP", want: 0.625, ok: true},
+		{from: "EUR", to: "USD", want: 1.25, ok: true},
+		{from: "GBP", to: "JPY", want: 300, ok: true},
+		{from: "EUR", to: "INR", ok: false},
+		{from: "INR", to: "EUR", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := crossRate(usdTable, tt.from, tt.to)
+		if ok != tt.ok || math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("crossRate(%s, %s) = %v, %v; want %v, %v", tt.from, tt.to, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDeriveTable(t *testing.T) {
+	derived := deriveTable(usdTable, "EUR")
+	if derived == nil {
+		t.Fatal("expected a derived table")
+	}
+	if derived.BaseCode != "EUR" || derived.Pivot != "USD" || derived.Provider != "fake" {
+		t.Fatalf("unexpected derived table metadata: %+v", derived)
+	}
+	if derived.ConversionRates["EUR"] != 1 || derived.ConversionRates["USD"] != 1.25 || derived.ConversionRates["GBP"] != 0.625 {
+		t.Fatalf("unexpected derived rates: %v", derived.ConversionRates)
+	}
+	if usdTable.BaseCode != "USD" || usdTable.ConversionRates["EUR"] != 0.8 {
+		t.Fatal("expected the pivot table to be left untouched")
+	}
+
+	if deriveTable(usdTable, "INR") != nil {
+		t.Fatal("expected no table for a currency missing from the pivot table")
+	}
+}
+
+func newPivotUseCase(external *fakeExternalRepo) domain_exchange.ExchangeRateUsercase {
+	return NewExchangeRateUseCase(external, newFakeCacheRepo(), nil, testRegistry, Options{
+		MaxHistoricalDays: 90,
+		PivotCurrency:     "USD",
+	})
+}
+
+func TestGetLatestRate_TriangulatesThroughPivot(t *testing.T) {
+	external := &fakeExternalRepo{rates: usdTable.ConversionRates}
+	uc := newPivotUseCase(external)
+	ctx := context.Background()
+
+	rate, err := uc.GetLatestRate(ctx, "EUR", "GBP")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rate.Rate != 0.625 {
+		t.Fatalf("expected 0.5/0.8, got %v", rate.Rate)
+	}
+	if _, err := uc.GetLatestRate(ctx, "GBP", "JPY"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(external.latestBases, []string{"USD"}) {
+		t.Fatalf("expected only the pivot table to be fetched, got %v", external.latestBases)
+	}
+}
+
+func TestRefreshRates_FetchesOnlyPivotAndOtherTypes(t *testing.T) {
+	external := &fakeExternalRepo{rates: map[string]float64{"USD": 1, "EUR": 0.8, "GBP": 0.5, "INR": 83, "JPY": 150, "BTC": 0.00001}}
+	uc := newPivotUseCase(external)
+
+	result, err := uc.RefreshRates(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// BTC is crypto and cannot be derived from the fiat pivot table.
+	if want := []string{"BTC", "USD"}; !slices.Equal(result.Succeeded, want) {
+		t.Fatalf("expected %v to be fetched, got %v", want, result.Succeeded)
+	}
+}
+
+func TestGetHistoricalRate_TriangulatesThroughPivot(t *testing.T) {
+	external := &fakeExternalRepo{rates: usdTable.ConversionRates}
+	uc := newPivotUseCase(external).(*exchangeRateUseCase)
+	date := truncateToDay(time.Now().AddDate(0, 0, -2))
+
+	rate, err := uc.getHistoricalRate(context.Background(), "GBP", "EUR", date)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rate != 1.6 {
+		t.Fatalf("expected 0.8/0.5, got %v", rate)
+	}
+}
+
+func TestGetHistoricalRate_FallsBackToOwnTableWhenPivotLacksQuote(t *testing.T) {
+	external := tablesRepo{
+		"USD": {"USD": 1, "EUR": 0.8},
+		"INR": {"INR": 1, "EUR": 0.011},
+	}
+	uc := NewExchangeRateUseCase(external, newFakeCacheRepo(), nil, testRegistry, Options{
+		MaxHistoricalDays: 90,
+		PivotCurrency:     "USD",
+	}).(*exchangeRateUseCase)
+	date := truncateToDay(time.Now().AddDate(0, 0, -2))
+
+	rate, err := uc.getHistoricalRate(context.Background(), "INR", "EUR", date)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rate != 0.011 {
+		t.Fatalf("expected the rate from the INR table, got %v", rate)
+	}
+}
@@ -2,19 +2,140 @@ package exchange
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	domain_exchange "exchange-rate-service/internal/domain/exchange"
 	"exchange-rate-service/pkg/logger"
+	"exchange-rate-service/pkg/metrics"
 )
 
+// defaultRefreshWorkers is used when no refresh concurrency is configured.
+const defaultRefreshWorkers = 4
+
+// RefreshRates fetches a fresh latest table for every supported currency,
+// with at most refreshWorkers requests in flight, and waits for all of them
+// or the refresh deadline. With a pivot configured only the pivot table is
+// fetched for currencies that are derived from it. A base only answered with
+// a stale table counts as failed. The returned error joins every failure.
+func (s *exchangeRateUseCase) RefreshRates(ctx context.Context) (*domain_exchange.RefreshResult, error) {
+	if s.refreshTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.refreshTimeout)
+		defer cancel()
+	}
+
+	currencies := s.registry.List()
+	covered := make(map[string][]string, len(currencies))
+	var bases, derived []string
+	for _, currency := range currencies {
+		base := s.tableBase(currency.Code)
+		if _, exists := covered[base]; !exists {
+			bases = append(bases, base)
+		}
+		covered[base] = append(covered[base], currency.Code)
+		if base != currency.Code {
+			derived = append(derived, currency.Code)
+		}
+	}
+	logger.Infof("Starting rate refresh of %d tables for %d currencies", len(bases), len(currencies))
+
+	result := s.runBounded(ctx, bases, func(base string) error {
+		table, err := s.fetchLatestTable(ctx, base)
+		if err != nil {
+			return err
+		}
+		if table.Stale {
+			return domain_exchange.Errorf(domain_exchange.ErrUpstreamFailure, "only a stale table fetched at %s is available",
+				table.FetchedAt.Format(time.RFC3339))
+		}
+		return nil
+	})
+	for _, base := range result.Succeeded {
+		for _, code := range covered[base] {
+			metrics.LastSuccessfulRefresh.WithLabelValues(code).SetToCurrentTime()
+		}
+	}
+
+	if s.compareDirect && len(derived) > 0 {
+		comparison := s.runBounded(ctx, derived, func(code string) error {
+			return s.compareWithDirect(ctx, code)
+		})
+		for _, failure := range comparison.Failed {
+			logger.Warnf("Failed to compare %s with direct quotes: %v", failure.Currency, failure.Err)
+		}
+	}
+
+	errs := make([]error, 0, len(result.Failed))
+	for _, failure := range result.Failed {
+		errs = append(errs, fmt.Errorf("%s: %w", failure.Currency, failure.Err))
+	}
+	logger.Infof("Rate refresh completed: %d succeeded, %d failed", len(result.Succeeded), len(result.Failed))
+	return result, errors.Join(errs...)
+}
+
+// runBounded calls fn for every code with at most refreshWorkers calls in
+// flight and reports which codes succeeded. Codes not started before ctx is
+// done are reported as failed with the context's error.
+func (s *exchangeRateUseCase) runBounded(ctx context.Context, codes []string, fn func(code string) error) *domain_exchange.RefreshResult {
+	workers := s.refreshWorkers
+	if workers <= 0 {
+		workers = defaultRefreshWorkers
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		result = &domain_exchange.RefreshResult{}
+		slots  = make(chan struct{}, workers)
+	)
+	for _, code := range codes {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			result.Failed = append(result.Failed, domain_exchange.RefreshFailure{Currency: code, Err: ctx.Err()})
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			err := fn(code)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Failed = append(result.Failed, domain_exchange.RefreshFailure{Currency: code, Err: err})
+				return
+			}
+			result.Succeeded = append(result.Succeeded, code)
+		}()
+	}
+	wg.Wait()
+
+	sort.Strings(result.Succeeded)
+	sort.Slice(result.Failed, func(i, j int) bool {
+		return result.Failed[i].Currency < result.Failed[j].Currency
+	})
+	return result
+}
+
 func StartRateRefreshTicker(useCase domain_exchange.ExchangeRateUsercase, ctx context.Context, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	logger.Infof("Starting rate refresh ticker with interval: %v", interval)
 
-	if err := useCase.RefreshRates(ctx); err != nil {
+	if _, err := useCase.RefreshRates(ctx); err != nil {
 		logger.Errorf("Initial rate refresh failed: %v", err)
 	}
 
@@ -25,7 +146,7 @@ func StartRateRefreshTicker(useCase domain_exchange.ExchangeRateUsercase, ctx co
 			return
 		case <-ticker.C:
 			logger.Info("Refreshing exchange rates...")
-			if err := useCase.RefreshRates(ctx); err != nil {
+			if _, err := useCase.RefreshRates(ctx); err != nil {
 				logger.Errorf("Rate refresh failed: %v", err)
 			} else {
 				logger.Info("Exchange rates refreshed successfully")
@@ -0,0 +1,52 @@
+package exchange
+
+import (
+	"context"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+type tableKey struct {
+	base string
+	date time.Time
+}
+
+type tableEntry struct {
+	table *domain_exchange.ExchangeRate
+	err   error
+}
+
+// tableMemo remembers the tables resolved while serving one request, so
+// each base and day is looked up once however many pairs need it.
+type tableMemo map[tableKey]tableEntry
+
+// memoTable returns the table answering conversions from from on date, the
+// latest when date is zero.
+func (s *exchangeRateUseCase) memoTable(ctx context.Context, memo tableMemo, from, to string, date time.Time) (*domain_exchange.ExchangeRate, error) {
+	return s.memoTableOf(ctx, memo, s.tableBase(from), to, date)
+}
+
+// memoTableOf returns base's own table on date, never derived from the pivot.
+func (s *exchangeRateUseCase) memoTableOf(ctx context.Context, memo tableMemo, base, to string, date time.Time) (*domain_exchange.ExchangeRate, error) {
+	key := tableKey{base: base}
+	if !date.IsZero() {
+		key.date = domain_exchange.StartOfDay(date)
+	}
+	entry, exists := memo[key]
+	if !exists {
+		entry.table, entry.err = s.tableOn(ctx, key.base, to, date)
+		memo[key] = entry
+	}
+	return entry.table, entry.err
+}
+
+func (s *exchangeRateUseCase) tableOn(ctx context.Context, base, to string, date time.Time) (*domain_exchange.ExchangeRate, error) {
+	if date.IsZero() {
+		return s.baseLatestTable(ctx, base)
+	}
+	if cachedRate := s.cachedHistoricalTable(ctx, base, to, date); cachedRate != nil {
+		return cachedRate, nil
+	}
+	return s.fetchHistoricalTable(ctx, base, to, date)
+}
@@ -0,0 +1,100 @@
+package exchange
+
+import (
+	"context"
+	"time"
+
+	domain_exchange "exchange-rate-service/internal/domain/exchange"
+)
+
+const dayLayout = "2006-01-02"
+
+func (s *exchangeRateUseCase) GetTimeSeries(ctx context.Context, from, to string, start, end time.Time) (*domain_exchange.TimeSeries, error) {
+	if err := s.ValidateCurrencies(from, to); err != nil {
+		return nil, err
+	}
+	if start.IsZero() || end.IsZero() {
+		return nil, domain_exchange.Errorf(domain_exchange.ErrInvalidRequest, "start and end dates are required")
+	}
+	if end.Before(start) {
+		return nil, domain_exchange.Errorf(domain_exchange.ErrInvalidRequest, "end date cannot be before start date")
+	}
+	if err := s.ValidateDate(start, s.maxHistoricalDays); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateDate(end, s.maxHistoricalDays); err != nil {
+		return nil, err
+	}
+
+	base := s.tableBase(from)
+	days := daysBetween(start, end)
+	found := make(map[string]float64, len(days))
+
+	// Serve whatever the cache or history store already holds and collect the
+	// gaps into contiguous runs, each fetched upstream with one call per day.
+	var runs [][2]time.Time
+	for _, day := range days {
+		if cachedRate := s.cachedHistoricalTable(ctx, base, to, day); cachedRate != nil {
+			if rate, exists := crossRate(cachedRate, from, to); exists {
+				found[day.Format(dayLayout)] = rate
+				continue
+			}
+		}
+		if n := len(runs); n > 0 && runs[n-1][1].AddDate(0, 0, 1).Equal(day) {
+			runs[n-1][1] = day
+			continue
+		}
+		runs = append(runs, [2]time.Time{day, day})
+	}
+
+	for _, run := range runs {
+		rates, err := s.externalRepo.GetRatesForDateRange(ctx, base, to, run[0], run[1])
+		if err != nil {
+			return nil, domain_exchange.Errorf(domain_exchange.ErrUpstreamFailure, "failed to fetch rates for %s to %s between %s and %s: %w",
+				from, to, run[0].Format(dayLayout), run[1].Format(dayLayout), err)
+		}
+		for _, rate := range rates {
+			if rate == nil || rate.Date.IsZero() {
+				continue
+			}
+			conversionRate, exists := crossRate(rate, from, to)
+			if !exists {
+				continue
+			}
+			found[rate.Date.Format(dayLayout)] = conversionRate
+			s.storeHistoricalTable(ctx, rate)
+		}
+	}
+
+	series := &domain_exchange.TimeSeries{
+		From:        from,
+		To:          to,
+		Start:       days[0],
+		End:         days[len(days)-1],
+		Points:      make([]domain_exchange.RatePoint, 0, len(days)),
+		MissingDays: []time.Time{},
+	}
+	for _, day := range days {
+		if rate, exists := found[day.Format(dayLayout)]; exists {
+			series.Points = append(series.Points, domain_exchange.RatePoint{Date: day, Rate: rate})
+			continue
+		}
+		series.MissingDays = append(series.MissingDays, day)
+	}
+	return series, nil
+}
+
+func daysBetween(start, end time.Time) []time.Time {
+	start = truncateToDay(start)
+	end = truncateToDay(end)
+
+	var days []time.Time
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		days = append(days, d)
+	}
+	return days
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return domain_exchange.StartOfDay(t)
+}
@@ -1,28 +1,71 @@
 package cache
 
 import (
+	"container/list"
+	"fmt"
 	"sync"
 	"time"
+
+	"exchange-rate-service/pkg/metrics"
+)
+
+const (
+	// DefaultExpiration stores the entry with the TTL the cache was created with.
+	DefaultExpiration time.Duration = 0
+	// NoExpiration keeps the entry until it is overwritten or evicted.
+	NoExpiration time.Duration = -1
 )
 
 type Cache interface {
 	Set(key string, value any, ttl time.Duration) error
 	Get(key string) (any, bool)
+	Close() error
+}
+
+// Sizer lets cached values report their approximate footprint in bytes so
+// the in-memory cache can enforce Limits.MaxBytes.
+type Sizer interface {
+	Size() int64
+}
+
+// defaultItemSize is charged for values that do not implement Sizer.
+const defaultItemSize = 256
+
+// Limits bound the in-memory cache; a zero field means unlimited.
+type Limits struct {
+	MaxEntries int
+	MaxBytes   int64
 }
 
 type cacheItem struct {
+	key       string
 	value     any
+	size      int64
 	expiresAt time.Time
 }
 
+func (i *cacheItem) expired(now time.Time) bool {
+	return !i.expiresAt.IsZero() && now.After(i.expiresAt)
+}
+
 type inMemoryCache struct {
-	items map[string]*cacheItem
-	mutex sync.RWMutex
+	items      map[string]*list.Element
+	lru        *list.List
+	usedBytes  int64
+	defaultTTL time.Duration
+	limits     Limits
+	mutex      sync.Mutex
+	stop       chan struct{}
+	stopOnce   sync.Once
 }
 
-func NewInMemoryCache(defaultTTL time.Duration) Cache {
+func NewInMemoryCache(defaultTTL time.Duration, limits Limits) Cache {
 	cache := &inMemoryCache{
-		items: make(map[string]*cacheItem),
+		items:      make(map[string]*list.Element),
+		lru:        list.New(),
+		defaultTTL: defaultTTL,
+		limits:     limits,
+		stop:       make(chan struct{}),
 	}
 
 	// Start cleanup goroutine
@@ -32,46 +75,108 @@ func NewInMemoryCache(defaultTTL time.Duration) Cache {
 }
 
 func (c *inMemoryCache) Set(key string, value any, ttl time.Duration) error {
+	if ttl == DefaultExpiration {
+		ttl = c.defaultTTL
+	}
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	size := int64(len(key)) + defaultItemSize
+	if sizer, ok := value.(Sizer); ok {
+		size = int64(len(key)) + sizer.Size()
+	}
+	if c.limits.MaxBytes > 0 && size > c.limits.MaxBytes {
+		return fmt.Errorf("value of %d bytes exceeds cache limit of %d bytes", size, c.limits.MaxBytes)
+	}
+
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	c.items[key] = &cacheItem{
+	if element, exists := c.items[key]; exists {
+		c.remove(element)
+	}
+	c.items[key] = c.lru.PushFront(&cacheItem{
+		key:       key,
 		value:     value,
-		expiresAt: time.Now().Add(ttl),
+		size:      size,
+		expiresAt: expiresAt,
+	})
+	c.usedBytes += size
+
+	for c.limits.MaxEntries > 0 && c.lru.Len() > c.limits.MaxEntries {
+		c.evictOldest("max_entries")
 	}
+	for c.limits.MaxBytes > 0 && c.usedBytes > c.limits.MaxBytes {
+		c.evictOldest("max_bytes")
+	}
+	metrics.CacheSize.Set(float64(c.lru.Len()))
 
 	return nil
 }
 
 func (c *inMemoryCache) Get(key string) (any, bool) {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
-	item, exists := c.items[key]
+	element, exists := c.items[key]
 	if !exists {
 		return nil, false
 	}
 
-	if time.Now().After(item.expiresAt) {
-		delete(c.items, key)
+	item := element.Value.(*cacheItem)
+	if item.expired(time.Now()) {
 		return nil, false
 	}
 
+	c.lru.MoveToFront(element)
 	return item.value, true
 }
 
+// Close stops the cleanup goroutine. The cache stays usable afterwards but
+// expired entries are only dropped when overwritten or evicted.
+func (c *inMemoryCache) Close() error {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+	return nil
+}
+
+func (c *inMemoryCache) evictOldest(reason string) {
+	if oldest := c.lru.Back(); oldest != nil {
+		c.remove(oldest)
+		metrics.CacheEvictions.WithLabelValues(reason).Inc()
+	}
+}
+
+func (c *inMemoryCache) remove(element *list.Element) {
+	item := element.Value.(*cacheItem)
+	c.lru.Remove(element)
+	delete(c.items, item.key)
+	c.usedBytes -= item.size
+}
+
 func (c *inMemoryCache) cleanup() {
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		c.mutex.Lock()
-		now := time.Now()
-		for key, item := range c.items {
-			if now.After(item.expiresAt) {
-				delete(c.items, key)
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.mutex.Lock()
+			now := time.Now()
+			for _, element := range c.items {
+				if element.Value.(*cacheItem).expired(now) {
+					c.remove(element)
+					metrics.CacheEvictions.WithLabelValues("expired").Inc()
+				}
 			}
+			metrics.CacheSize.Set(float64(c.lru.Len()))
+			c.mutex.Unlock()
 		}
-		c.mutex.Unlock()
 	}
 }
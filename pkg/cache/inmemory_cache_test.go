@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInMemoryCache_UsesDefaultTTL(t *testing.T) {
+	c := NewInMemoryCache(20*time.Millisecond, Limits{})
+	t.Cleanup(func() { c.Close() })
+
+	if err := c.Set("rate:USD", "table", DefaultExpiration); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := c.Get("rate:USD"); !ok {
+		t.Fatal("expected entry before the default ttl elapses")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if _, ok := c.Get("rate:USD"); ok {
+		t.Fatal("expected entry to expire after the default ttl")
+	}
+}
+
+func TestInMemoryCache_NoExpiration(t *testing.T) {
+	c := NewInMemoryCache(time.Millisecond, Limits{})
+	t.Cleanup(func() { c.Close() })
+
+	if err := c.Set("rate:USD:2024-01-02", "table", NoExpiration); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if _, ok := c.Get("rate:USD:2024-01-02"); !ok {
+		t.Fatal("expected entry without expiry to stay cached")
+	}
+}
+
+type sizedValue int64
+
+func (v sizedValue) Size() int64 { return int64(v) }
+
+func TestInMemoryCache_EvictsLeastRecentlyUsedEntry(t *testing.T) {
+	c := NewInMemoryCache(time.Hour, Limits{MaxEntries: 2})
+	t.Cleanup(func() { c.Close() })
+
+	c.Set("a", "1", DefaultExpiration)
+	c.Set("b", "2", DefaultExpiration)
+	// Touch "a" so "b" becomes the least recently used entry.
+	c.Get("a")
+	c.Set("c", "3", DefaultExpiration)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("expected least recently used entry to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Fatalf("expected %s to stay cached", key)
+		}
+	}
+}
+
+func TestInMemoryCache_EnforcesMaxBytes(t *testing.T) {
+	c := NewInMemoryCache(time.Hour, Limits{MaxBytes: 250})
+	t.Cleanup(func() { c.Close() })
+
+	c.Set("a", sizedValue(100), DefaultExpiration)
+	c.Set("b", sizedValue(100), DefaultExpiration)
+	c.Set("c", sizedValue(100), DefaultExpiration)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected oldest entry to be evicted once the byte limit is exceeded")
+	}
+	if _, ok := c.Get("c"); !ok {
+		t.Fatal("expected newest entry to stay cached")
+	}
+
+	if err := c.Set("huge", sizedValue(1000), DefaultExpiration); err == nil {
+		t.Fatal("expected error for a value larger than the cache")
+	}
+}
+
+func TestInMemoryCache_CloseIsIdempotent(t *testing.T) {
+	c := NewInMemoryCache(time.Hour, Limits{})
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("unexpected error on second close: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"exchange-rate-service/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Codec converts cached values to and from the bytes stored in Redis.
+type Codec interface {
+	Marshal(value any) ([]byte, error)
+	Unmarshal(data []byte) (any, error)
+}
+
+type redisCache struct {
+	client     *redis.Client
+	prefix     string
+	defaultTTL time.Duration
+	timeout    time.Duration
+	codec      Codec
+}
+
+func NewRedisCache(client *redis.Client, prefix string, defaultTTL time.Duration, codec Codec) Cache {
+	return &redisCache{
+		client:     client,
+		prefix:     prefix,
+		defaultTTL: defaultTTL,
+		timeout:    2 * time.Second,
+		codec:      codec,
+	}
+}
+
+func (c *redisCache) Set(key string, value any, ttl time.Duration) error {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case ttl == DefaultExpiration:
+		ttl = c.defaultTTL
+	case ttl < 0:
+		// A zero expiration makes Redis keep the key indefinitely.
+		ttl = 0
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	return c.client.Set(ctx, c.prefix+key, data, ttl).Err()
+}
+
+// Get treats Redis being unreachable as a miss so callers fall back to the
+// upstream API instead of failing the request.
+func (c *redisCache) Get(key string) (any, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	data, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logger.Errorf("Redis get %s failed: %v", key, err)
+		}
+		return nil, false
+	}
+
+	value, err := c.codec.Unmarshal(data)
+	if err != nil {
+		logger.Errorf("Failed to decode cached value %s: %v", key, err)
+		return nil, false
+	}
+	return value, true
+}
+
+func (c *redisCache) Close() error {
+	return c.client.Close()
+}
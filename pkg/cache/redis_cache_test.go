@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type stringCodec struct{}
+
+func (stringCodec) Marshal(value any) ([]byte, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, errors.New("not a string")
+	}
+	return []byte(s), nil
+}
+
+func (stringCodec) Unmarshal(data []byte) (any, error) {
+	return string(data), nil
+}
+
+func newTestRedisCache(t *testing.T, defaultTTL time.Duration) (Cache, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisCache(client, "test:", defaultTTL, stringCodec{}), mr
+}
+
+func TestRedisCache_SetGet(t *testing.T) {
+	c, mr := newTestRedisCache(t, time.Hour)
+
+	if err := c.Set("rate:USD", "table", time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	value, ok := c.Get("rate:USD")
+	if !ok || value != "table" {
+		t.Fatalf("expected cached table, got %v ok=%v", value, ok)
+	}
+	if !mr.Exists("test:rate:USD") {
+		t.Fatal("expected key to be stored under the configured prefix")
+	}
+	if ttl := mr.TTL("test:rate:USD"); ttl != time.Minute {
+		t.Fatalf("expected ttl of 1m, got %v", ttl)
+	}
+}
+
+func TestRedisCache_Expiry(t *testing.T) {
+	c, mr := newTestRedisCache(t, time.Hour)
+
+	if err := c.Set("rate:EUR", "table", time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mr.FastForward(2 * time.Minute)
+
+	if _, ok := c.Get("rate:EUR"); ok {
+		t.Fatal("expected entry to expire")
+	}
+}
+
+func TestRedisCache_DefaultTTL(t *testing.T) {
+	c, mr := newTestRedisCache(t, 30*time.Minute)
+
+	if err := c.Set("rate:GBP", "table", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ttl := mr.TTL("test:rate:GBP"); ttl != 30*time.Minute {
+		t.Fatalf("expected default ttl of 30m, got %v", ttl)
+	}
+}
+
+func TestRedisCache_UnavailableIsMiss(t *testing.T) {
+	c, mr := newTestRedisCache(t, time.Hour)
+	mr.Close()
+
+	if _, ok := c.Get("rate:USD"); ok {
+		t.Fatal("expected miss when redis is unavailable")
+	}
+}
+
+func TestRedisCache_NoExpiration(t *testing.T) {
+	c, mr := newTestRedisCache(t, 30*time.Minute)
+
+	if err := c.Set("rate:JPY:2024-01-02", "table", NoExpiration); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ttl := mr.TTL("test:rate:JPY:2024-01-02"); ttl != 0 {
+		t.Fatalf("expected key without expiry, got ttl %v", ttl)
+	}
+}
@@ -39,6 +39,22 @@ var (
 		[]string{"cache_type"},
 	)
 
+	LastSuccessfulRefresh = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "rate_refresh_last_success_timestamp_seconds",
+			Help: "Unix time of the last successful refresh of a base currency's latest table",
+		},
+		[]string{"currency"},
+	)
+
+	CoalescedRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "coalesced_requests_total",
+			Help: "Total number of cache misses that shared an in-flight upstream fetch",
+		},
+		[]string{"kind"},
+	)
+
 	ExternalAPIRequests = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "external_api_requests_total",
@@ -56,6 +72,54 @@ var (
 		[]string{"api_endpoint", "status"},
 	)
 
+	ExternalAPIRetries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "external_api_retries_total",
+			Help: "Total number of retried external API requests",
+		},
+		[]string{"provider", "reason"},
+	)
+
+	ProviderResponses = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "provider_responses_total",
+			Help: "Total number of answers from upstream providers by outcome",
+		},
+		[]string{"provider", "outcome"},
+	)
+
+	ProviderRateDeviation = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "provider_rate_deviation_bps",
+			Help: "Largest deviation of a provider's rates from the consensus median in basis points",
+		},
+		[]string{"provider", "base_currency"},
+	)
+
+	ProviderOutliers = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "provider_outliers_total",
+			Help: "Total number of provider tables deviating beyond the consensus threshold",
+		},
+		[]string{"provider"},
+	)
+
+	PivotDeviation = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pivot_rate_deviation_bps",
+			Help: "Largest deviation of rates derived through the pivot currency from direct quotes in basis points",
+		},
+		[]string{"base_currency"},
+	)
+
+	CircuitBreakerState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "circuit_breaker_state",
+			Help: "Circuit breaker state per upstream provider (0 closed, 1 half-open, 2 open)",
+		},
+		[]string{"provider"},
+	)
+
 	ActiveConnections = promauto.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "active_connections",
@@ -69,4 +133,12 @@ var (
 			Help: "Current size of the cache",
 		},
 	)
+
+	CacheEvictions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_evictions_total",
+			Help: "Total number of entries evicted from the in-memory cache",
+		},
+		[]string{"reason"},
+	)
 )